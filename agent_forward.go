@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// Agent sources for SSHParams.ForwardAgent
+const (
+	agentSourceSystem = "system" // the local ssh-agent, see dialAgent
+	agentSourceVault  = "vault"  // this profile's own stored key only
+)
+
+// enableAgentForwarding serves agent channels on conn (once per connection) and asks
+// the server to expose them to sess. Must be called before the shell starts.
+func (tm *TermManager) enableAgentForwarding(conn *sharedConn, sess *ssh.Session, p SSHParams) error {
+	// held across the registration so two tabs on conn cannot both register a handler
+	tm.connMu.Lock()
+	source := conn.agentSource
+	if source == "" {
+		var err error
+		switch p.ForwardAgent {
+		case agentSourceSystem:
+			err = forwardSystemAgent(conn.client)
+		case agentSourceVault:
+			var kr agent.Agent
+			if kr, err = vaultKeyring(p); err == nil {
+				err = agent.ForwardToAgent(conn.client, kr)
+			}
+		default:
+			err = fmt.Errorf("unsupported agent source: %s", p.ForwardAgent)
+		}
+		if err != nil {
+			tm.connMu.Unlock()
+			return fmt.Errorf("agent forwarding: %w", err)
+		}
+		conn.agentSource = p.ForwardAgent
+		source = p.ForwardAgent
+	}
+	tm.connMu.Unlock()
+	if source != p.ForwardAgent {
+		log.Printf("[Agent] connection %s already forwards the %s agent, ignoring %s", conn.label, source, p.ForwardAgent)
+	}
+
+	if err := agent.RequestAgentForwarding(sess); err != nil {
+		// the shell is still useful without it, e.g. AllowAgentForwarding no
+		log.Printf("[Agent] server refused agent forwarding for %s: %v", conn.label, err)
+	}
+	return nil
+}
+
+const (
+	agentChannelType = "auth-agent@openssh.com"
+	// largest agent message relayed, as in x/crypto/ssh/agent
+	maxAgentMsg = 16 << 20
+)
+
+// forwardSystemAgent serves the server's agent channels from the local ssh-agent.
+// Unlike agent.ForwardToRemote it reaches the agent through dialAgent, so the
+// Windows named pipe works too.
+func forwardSystemAgent(client *ssh.Client) error {
+	if !agentAvailable() {
+		return errors.New("agent forwarding requires a running ssh-agent")
+	}
+	chans := client.HandleChannelOpen(agentChannelType)
+	if chans == nil {
+		return errors.New("agent channels are already handled")
+	}
+	go func() {
+		for nc := range chans {
+			ch, reqs, err := nc.Accept()
+			if err != nil {
+				continue
+			}
+			go ssh.DiscardRequests(reqs)
+			go relayAgent(ch)
+		}
+	}()
+	return nil
+}
+
+// relayAgent passes the requests on ch to a fresh local agent connection and the
+// replies back, one message at a time, as pipe handles cannot be read and written at once
+func relayAgent(ch ssh.Channel) {
+	defer ch.Close()
+	ag, err := dialAgent()
+	if err != nil {
+		log.Printf("[Agent] forwarded request dropped: %v", err)
+		return
+	}
+	defer ag.Close()
+	for {
+		if copyAgentMsg(ag, ch) != nil || copyAgentMsg(ch, ag) != nil {
+			return
+		}
+	}
+}
+
+// copyAgentMsg copies one length-prefixed agent protocol message from src to dst
+func copyAgentMsg(dst io.Writer, src io.Reader) error {
+	var hdr [4]byte
+	if _, err := io.ReadFull(src, hdr[:]); err != nil {
+		return err
+	}
+	n := binary.BigEndian.Uint32(hdr[:])
+	if n > maxAgentMsg {
+		return fmt.Errorf("agent message too large: %d bytes", n)
+	}
+	if _, err := dst.Write(hdr[:]); err != nil {
+		return err
+	}
+	_, err := io.CopyN(dst, src, int64(n))
+	return err
+}
+
+// vaultKeyring loads the session's own key into an in-memory agent. Keys of other
+// profiles are deliberately left out: anyone with root on the server can use a
+// forwarded agent, so it must not unlock hosts this profile never logs in to.
+func vaultKeyring(p SSHParams) (agent.Agent, error) {
+	if p.AuthType != "key" || p.KeyPEM == "" {
+		return nil, errors.New("vault agent forwarding requires key authentication for this profile")
+	}
+	var raw any
+	var err error
+	if p.Passphrase != "" {
+		raw, err = ssh.ParseRawPrivateKeyWithPassphrase([]byte(p.KeyPEM), []byte(p.Passphrase))
+	} else {
+		raw, err = ssh.ParseRawPrivateKey([]byte(p.KeyPEM))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse key: %w", err)
+	}
+	key := agent.AddedKey{PrivateKey: raw, Comment: p.Username + "@" + p.Host}
+	kr := agent.NewKeyring()
+	if p.CertPub != "" {
+		// a keyring entry with a certificate offers only the certificate, so the
+		// bare key is added too, as ssh-add does, for servers that don't trust the CA
+		if cert, err := parseUserCert(p.CertPub); err == nil {
+			withCert := key
+			withCert.Certificate = cert
+			if err := kr.Add(withCert); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := kr.Add(key); err != nil {
+		return nil, err
+	}
+	log.Printf("[Agent] forwarding the vault key of %s", key.Comment)
+	return kr, nil
+}
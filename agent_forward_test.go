@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"encoding/pem"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestCopyAgentMsg(t *testing.T) {
+	msg := []byte{0, 0, 0, 3, 11, 'a', 'b'}
+	src := bytes.NewReader(append(append([]byte{}, msg...), 0, 0, 0, 1, 5))
+	var dst bytes.Buffer
+	if err := copyAgentMsg(&dst, src); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dst.Bytes(), msg) || src.Len() != 5 {
+		t.Errorf("copied % x leaving %d bytes, want % x leaving 5", dst.Bytes(), src.Len(), msg)
+	}
+
+	for name, in := range map[string][]byte{
+		"too large": {0x7f, 0xff, 0xff, 0xff},
+		"truncated": {0, 0, 0, 9, 1},
+		"no header": {0, 0},
+	} {
+		if err := copyAgentMsg(io.Discard, bytes.NewReader(in)); err == nil {
+			t.Errorf("%s: copied", name)
+		}
+	}
+}
+
+func TestVaultKeyring(t *testing.T) {
+	key, priv := newTestSigner(t)
+	block, err := ssh.MarshalPrivateKeyWithPassphrase(priv, "", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, _ := newTestSigner(t)
+	p := SSHParams{Host: "db", Username: "ops", AuthType: "key", KeyPEM: string(pem.EncodeToMemory(block)), Passphrase: "secret",
+		CertPub: certLine(signCert(t, ca, key.PublicKey(), ssh.UserCert, 0, ssh.CertTimeInfinity))}
+	kr, err := vaultKeyring(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := kr.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the certificate and the bare key, nothing else
+	if len(keys) != 2 || keys[0].Comment != "ops@db" {
+		t.Fatalf("keyring holds %v", keys)
+	}
+	for _, k := range keys {
+		if !strings.HasPrefix(k.Format, key.PublicKey().Type()) {
+			t.Errorf("unexpected key %s", k.Format)
+		}
+	}
+
+	for _, bad := range []SSHParams{
+		{AuthType: "password", Password: "pw"},
+		{AuthType: "agent"},
+		{AuthType: "key", KeyPEM: p.KeyPEM},                      // passphrase missing
+		{AuthType: "key", KeyPEM: p.KeyPEM, Passphrase: "wrong"}, // wrong passphrase
+	} {
+		if _, err := vaultKeyring(bad); err == nil {
+			t.Errorf("vaultKeyring(%s) succeeded", bad.AuthType)
+		}
+	}
+}
+
+// pipeChannel is an ssh.Channel over one end of a net.Pipe
+type pipeChannel struct{ net.Conn }
+
+func (pipeChannel) CloseWrite() error                              { return nil }
+func (pipeChannel) SendRequest(string, bool, []byte) (bool, error) { return false, nil }
+func (c pipeChannel) Stderr() io.ReadWriter                        { return c.Conn }
+
+func TestRelayAgent(t *testing.T) {
+	_, priv := newTestSigner(t)
+	serveTestAgent(t, priv)
+	remote, local := net.Pipe()
+	defer remote.Close()
+	go relayAgent(pipeChannel{local})
+
+	keys, err := agent.NewClient(remote).List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 {
+		t.Errorf("relayed agent lists %d keys, want 1", len(keys))
+	}
+}
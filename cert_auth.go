@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// CertInfo describes an OpenSSH user certificate
+type CertInfo struct {
+	KeyID        string   `json:"keyId"`
+	Serial       uint64   `json:"serial"`
+	Principals   []string `json:"principals"`
+	ValidAfter   string   `json:"validAfter,omitempty"`  // RFC3339, empty = always
+	ValidBefore  string   `json:"validBefore,omitempty"` // RFC3339, empty = forever
+	Expired      bool     `json:"expired"`
+	NotYetValid  bool     `json:"notYetValid"`
+	CA           string   `json:"ca"` // SHA256 fingerprint of the signing CA
+	Extensions   []string `json:"extensions,omitempty"`
+	ForceCommand string   `json:"forceCommand,omitempty"`
+}
+
+// AuthInspection summarises the credentials of one hop without revealing them
+type AuthInspection struct {
+	Type        string    `json:"type"`
+	KeyType     string    `json:"keyType,omitempty"`
+	Fingerprint string    `json:"fingerprint,omitempty"`
+	Encrypted   bool      `json:"encrypted,omitempty"` // key needs a passphrase that is not stored
+	Cert        *CertInfo `json:"cert,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// ProfileInspection is what InspectProfile reports for a saved host
+type ProfileInspection struct {
+	ID    string           `json:"id"`
+	Name  string           `json:"name"`
+	Auth  AuthInspection   `json:"auth"`
+	Jumps []AuthInspection `json:"jumps,omitempty"`
+}
+
+// InspectProfile reports key fingerprints and certificate validity/principals of a
+// saved profile and its jump hosts
+func (pm *ProfilesManager) InspectProfile(id string) (ProfileInspection, error) {
+	p, err := pm.GetProfile(id)
+	if err != nil {
+		return ProfileInspection{}, err
+	}
+	out := ProfileInspection{ID: p.ID, Name: p.Name, Auth: inspectAuth(p.Auth)}
+	for _, j := range p.Jumps {
+		out.Jumps = append(out.Jumps, inspectAuth(j.Auth))
+	}
+	return out, nil
+}
+
+// InspectCertificate parses an OpenSSH certificate (the content of id_*-cert.pub)
+func (pm *ProfilesManager) InspectCertificate(certPub string) (CertInfo, error) {
+	cert, err := parseUserCert(certPub)
+	if err != nil {
+		return CertInfo{}, err
+	}
+	return describeCert(cert, time.Now()), nil
+}
+
+func inspectAuth(a AuthInfo) AuthInspection {
+	out := AuthInspection{Type: a.Type}
+	if out.Type == "" {
+		out.Type = "password"
+	}
+	if a.Type != "key" {
+		return out
+	}
+	if signer, err := parseSigner(a.KeyPEM, a.Passphrase); err == nil {
+		out.KeyType = signer.PublicKey().Type()
+		out.Fingerprint = ssh.FingerprintSHA256(signer.PublicKey())
+	} else {
+		var missing *ssh.PassphraseMissingError
+		if errors.As(err, &missing) {
+			out.Encrypted = true
+			if missing.PublicKey != nil {
+				out.KeyType = missing.PublicKey.Type()
+				out.Fingerprint = ssh.FingerprintSHA256(missing.PublicKey)
+			}
+		} else {
+			out.Error = err.Error()
+		}
+	}
+	if a.CertPub != "" {
+		cert, err := parseUserCert(a.CertPub)
+		if err != nil {
+			out.Error = err.Error()
+			return out
+		}
+		info := describeCert(cert, time.Now())
+		out.Cert = &info
+	}
+	return out
+}
+
+func parseUserCert(certPub string) (*ssh.Certificate, error) {
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(certPub))
+	if err != nil {
+		return nil, fmt.Errorf("parse certificate: %w", err)
+	}
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		return nil, errors.New("not an OpenSSH certificate (expected the content of id_*-cert.pub)")
+	}
+	if cert.CertType != ssh.UserCert {
+		return nil, errors.New("certificate is a host certificate, not a user certificate")
+	}
+	return cert, nil
+}
+
+func describeCert(cert *ssh.Certificate, now time.Time) CertInfo {
+	info := CertInfo{
+		KeyID:        cert.KeyId,
+		Serial:       cert.Serial,
+		Principals:   cert.ValidPrincipals,
+		CA:           ssh.FingerprintSHA256(cert.SignatureKey),
+		ForceCommand: cert.CriticalOptions["force-command"],
+	}
+	if cert.ValidAfter != 0 {
+		info.ValidAfter = certTime(cert.ValidAfter).Format(time.RFC3339)
+	}
+	if cert.ValidBefore != ssh.CertTimeInfinity {
+		info.ValidBefore = certTime(cert.ValidBefore).Format(time.RFC3339)
+	}
+	unix := uint64(now.Unix())
+	info.Expired = cert.ValidBefore != ssh.CertTimeInfinity && unix >= cert.ValidBefore
+	info.NotYetValid = unix < cert.ValidAfter
+	for ext := range cert.Extensions {
+		info.Extensions = append(info.Extensions, ext)
+	}
+	sort.Strings(info.Extensions)
+	return info
+}
+
+func certTime(t uint64) time.Time {
+	if t > uint64(1<<63-1) {
+		t = 1<<63 - 1
+	}
+	return time.Unix(int64(t), 0)
+}
+
+// certSigner wraps signer with its user certificate, refusing certificates that
+// are expired, not yet valid or issued for a different key
+func certSigner(certPub string, signer ssh.Signer) (ssh.Signer, error) {
+	cert, err := parseUserCert(certPub)
+	if err != nil {
+		return nil, err
+	}
+	info := describeCert(cert, time.Now())
+	if info.Expired {
+		return nil, fmt.Errorf("certificate %q expired at %s, request a new one from your CA", cert.KeyId, info.ValidBefore)
+	}
+	if info.NotYetValid {
+		return nil, fmt.Errorf("certificate %q is not valid before %s (check the local clock)", cert.KeyId, info.ValidAfter)
+	}
+	cs, err := ssh.NewCertSigner(cert, signer)
+	if err != nil {
+		return nil, fmt.Errorf("certificate %q: %w", cert.KeyId, err)
+	}
+	return cs, nil
+}
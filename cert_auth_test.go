@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/pem"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// signCert issues a certificate for key, signed by ca, valid over [after, before)
+func signCert(t *testing.T, ca ssh.Signer, key ssh.PublicKey, certType uint32, after, before uint64) *ssh.Certificate {
+	t.Helper()
+	cert := &ssh.Certificate{
+		Key: key, Serial: 7, CertType: certType, KeyId: "ops@example",
+		ValidPrincipals: []string{"ops", "deploy"}, ValidAfter: after, ValidBefore: before,
+		Permissions: ssh.Permissions{
+			CriticalOptions: map[string]string{"force-command": "uptime"},
+			Extensions:      map[string]string{"permit-pty": "", "permit-agent-forwarding": ""},
+		},
+	}
+	if err := cert.SignCert(rand.Reader, ca); err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func certLine(c *ssh.Certificate) string { return string(ssh.MarshalAuthorizedKey(c)) }
+
+func TestDescribeCert(t *testing.T) {
+	ca, _ := newTestSigner(t)
+	key, _ := newTestSigner(t)
+	now := time.Unix(1_700_000_000, 0)
+	at := uint64(now.Unix())
+	tests := []struct {
+		name                 string
+		after, before        uint64
+		expired, notYetValid bool
+		validBefore          string
+	}{
+		{"valid", at - 60, at + 60, false, false, now.Add(time.Minute).Format(time.RFC3339)},
+		{"expired", at - 120, at, true, false, now.Format(time.RFC3339)},
+		{"not yet valid", at + 60, at + 120, false, true, now.Add(2 * time.Minute).Format(time.RFC3339)},
+		{"forever", 0, ssh.CertTimeInfinity, false, false, ""},
+	}
+	for _, tt := range tests {
+		info := describeCert(signCert(t, ca, key.PublicKey(), ssh.UserCert, tt.after, tt.before), now)
+		if info.Expired != tt.expired || info.NotYetValid != tt.notYetValid || info.ValidBefore != tt.validBefore {
+			t.Errorf("%s: expired=%v notYetValid=%v validBefore=%q, want %v %v %q",
+				tt.name, info.Expired, info.NotYetValid, info.ValidBefore, tt.expired, tt.notYetValid, tt.validBefore)
+		}
+		if tt.after == 0 && info.ValidAfter != "" {
+			t.Errorf("%s: ValidAfter = %q, want empty", tt.name, info.ValidAfter)
+		}
+	}
+
+	info := describeCert(signCert(t, ca, key.PublicKey(), ssh.UserCert, 0, ssh.CertTimeInfinity), now)
+	if info.KeyID != "ops@example" || info.Serial != 7 || info.ForceCommand != "uptime" || info.CA != ssh.FingerprintSHA256(ca.PublicKey()) ||
+		!slices.Equal(info.Principals, []string{"ops", "deploy"}) ||
+		!slices.Equal(info.Extensions, []string{"permit-agent-forwarding", "permit-pty"}) {
+		t.Errorf("describeCert = %+v", info)
+	}
+}
+
+func TestParseUserCert(t *testing.T) {
+	ca, _ := newTestSigner(t)
+	key, _ := newTestSigner(t)
+	if _, err := parseUserCert(certLine(signCert(t, ca, key.PublicKey(), ssh.UserCert, 0, ssh.CertTimeInfinity))); err != nil {
+		t.Errorf("user certificate rejected: %v", err)
+	}
+	bad := map[string]string{
+		"host certificate": certLine(signCert(t, ca, key.PublicKey(), ssh.HostCert, 0, ssh.CertTimeInfinity)),
+		"plain key":        string(ssh.MarshalAuthorizedKey(key.PublicKey())),
+		"garbage":          "ssh-ed25519-cert-v01@openssh.com AAAA",
+	}
+	for name, line := range bad {
+		if _, err := parseUserCert(line); err == nil {
+			t.Errorf("%s accepted", name)
+		}
+	}
+}
+
+func TestCertSigner(t *testing.T) {
+	ca, _ := newTestSigner(t)
+	key, _ := newTestSigner(t)
+	other, _ := newTestSigner(t)
+	now := uint64(time.Now().Unix())
+	tests := []struct {
+		name    string
+		cert    *ssh.Certificate
+		wantErr string
+	}{
+		{"valid", signCert(t, ca, key.PublicKey(), ssh.UserCert, now-60, now+3600), ""},
+		{"expired", signCert(t, ca, key.PublicKey(), ssh.UserCert, now-7200, now-3600), "expired"},
+		{"not yet valid", signCert(t, ca, key.PublicKey(), ssh.UserCert, now+3600, now+7200), "not valid before"},
+		{"other key", signCert(t, ca, other.PublicKey(), ssh.UserCert, 0, ssh.CertTimeInfinity), "ops@example"},
+	}
+	for _, tt := range tests {
+		s, err := certSigner(certLine(tt.cert), key)
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil {
+			if _, ok := s.PublicKey().(*ssh.Certificate); !ok {
+				t.Errorf("%s: signer presents %T, want a certificate", tt.name, s.PublicKey())
+			}
+		}
+	}
+}
+
+func TestCertAuth(t *testing.T) {
+	ca, _ := newTestSigner(t)
+	key, priv := newTestSigner(t)
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := signCert(t, ca, key.PublicKey(), ssh.UserCert, 0, ssh.CertTimeInfinity)
+	methods, release, err := NewTermManager().authMethods(AuthInfo{Type: "key", KeyPEM: string(pem.EncodeToMemory(block)), CertPub: certLine(cert)}, "h", "ops")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	// the server trusts the CA only, so the bare key alone would be refused
+	checker := &ssh.CertChecker{
+		IsUserAuthority: func(auth ssh.PublicKey) bool {
+			return bytes.Equal(auth.Marshal(), ca.PublicKey().Marshal())
+		},
+		SupportedCriticalOptions: []string{"force-command"},
+	}
+	if err := handshake(t, methods, &ssh.ServerConfig{PublicKeyCallback: checker.Authenticate}); err != nil {
+		t.Errorf("certificate login failed: %v", err)
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"golang.org/x/crypto/ssh"
+
+	"xgoterm/internal/store"
+)
+
+// execOutputLimit caps the stdout/stderr kept in a CommandResult; streamed output is not capped
+const execOutputLimit = 8 << 20
+
+// CommandResult is the outcome of a non-interactive command
+type CommandResult struct {
+	ID         string `json:"id"`
+	ExitCode   int    `json:"exitCode"`         // -1 when the server reported no exit status
+	Signal     string `json:"signal,omitempty"` // e.g. "KILL" when the command died from a signal
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+	Truncated  bool   `json:"truncated,omitempty"` // output exceeded execOutputLimit
+	TimedOut   bool   `json:"timedOut,omitempty"`
+	Canceled   bool   `json:"canceled,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// CommandOutput is the payload of exec:output:<id> events
+type CommandOutput struct {
+	ID     string `json:"id"`
+	Stream string `json:"stream"` // stdout | stderr
+	Data   string `json:"data"`
+}
+
+// RunCommand runs cmd on an exec channel and waits for it to finish. target is
+// an open session id, whose connection is reused, or a saved profile id.
+// timeoutSec <= 0 means no timeout. Pass an id to be able to CancelCommand the
+// call from elsewhere; an empty id gets a generated one.
+func (tm *TermManager) RunCommand(id, target, cmd string, timeoutSec int) (CommandResult, error) {
+	if id == "" {
+		id = fmt.Sprintf("exec-%d", time.Now().UnixNano())
+	}
+	ctx, cancel, err := tm.execContext(id, timeoutSec)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	defer cancel()
+	return tm.execCommand(ctx, id, target, cmd, false)
+}
+
+// StartCommand is the streaming variant of RunCommand. It returns once the
+// command is running, emits exec:output:<id> while it runs and exec:done:<id>
+// with the CommandResult (or exec:error:<id>) when it ends. The caller picks id
+// so it can subscribe before any output arrives; CancelCommand(id) stops it.
+func (tm *TermManager) StartCommand(id, target, cmd string, timeoutSec int) error {
+	if id == "" {
+		return errors.New("command id required")
+	}
+	ctx, cancel, err := tm.execContext(id, timeoutSec)
+	if err != nil {
+		return err
+	}
+	conn, err := tm.execConn(ctx, target)
+	if err != nil {
+		cancel()
+		return err
+	}
+	go func() {
+		defer cancel()
+		defer tm.releaseConn(conn)
+		res, err := tm.runOn(ctx, conn, id, cmd, true)
+		if err != nil {
+			runtime.EventsEmit(tm.ctx, "exec:error:"+id, err.Error())
+			return
+		}
+		runtime.EventsEmit(tm.ctx, "exec:done:"+id, res)
+	}()
+	return nil
+}
+
+// CancelCommand stops a command started by RunCommand or StartCommand
+func (tm *TermManager) CancelCommand(id string) error {
+	tm.execMu.Lock()
+	cancel, ok := tm.execs[id]
+	tm.execMu.Unlock()
+	if !ok {
+		return errors.New("command not found")
+	}
+	cancel()
+	return nil
+}
+
+// execContext registers a cancelable context for command id, unless one is running
+func (tm *TermManager) execContext(id string, timeoutSec int) (context.Context, context.CancelFunc, error) {
+	tm.execMu.Lock()
+	defer tm.execMu.Unlock()
+	if _, busy := tm.execs[id]; busy {
+		return nil, nil, fmt.Errorf("command %s is already running", id)
+	}
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeoutSec > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), time.Duration(timeoutSec)*time.Second)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	tm.execs[id] = cancel
+	return ctx, func() {
+		tm.execMu.Lock()
+		delete(tm.execs, id)
+		tm.execMu.Unlock()
+		cancel()
+	}, nil
+}
+
+func (tm *TermManager) execCommand(ctx context.Context, id, target, cmd string, stream bool) (CommandResult, error) {
+	conn, err := tm.execConn(ctx, target)
+	if err != nil {
+		return CommandResult{}, err
+	}
+	defer tm.releaseConn(conn)
+	return tm.runOn(ctx, conn, id, cmd, stream)
+}
+
+// execConn returns a retained connection for a session id or profile id,
+// giving up on dialing the profile once ctx is done
+func (tm *TermManager) execConn(ctx context.Context, target string) (*sharedConn, error) {
+	if s, ok := tm.getSSH(target); ok {
+		conn := s.sshConn()
+		tm.retainConn(conn)
+		return conn, nil
+	}
+	p, err := tm.profileParams(target)
+	if err != nil {
+		return nil, fmt.Errorf("no open session or saved profile %q: %w", target, err)
+	}
+	return tm.acquireConn(ctx, p)
+}
+
+// runOn executes cmd on conn until it exits or ctx is done
+func (tm *TermManager) runOn(ctx context.Context, conn *sharedConn, id, cmd string, stream bool) (CommandResult, error) {
+	sess, err := conn.client.NewSession()
+	if err != nil {
+		return CommandResult{}, err
+	}
+	defer sess.Close()
+
+	res := CommandResult{ID: id}
+	stdout := &execWriter{tm: tm, id: id, stream: "stdout", emit: stream}
+	stderr := &execWriter{tm: tm, id: id, stream: "stderr", emit: stream}
+	sess.Stdout, sess.Stderr = stdout, stderr
+
+	start := time.Now()
+	if err := sess.Start(cmd); err != nil {
+		return CommandResult{}, err
+	}
+	done := make(chan error, 1)
+	go func() { done <- sess.Wait() }()
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		// not every server honours signals, closing the channel always ends the wait
+		_ = sess.Signal(ssh.SIGTERM)
+		_ = sess.Close()
+		err = <-done
+		res.TimedOut = errors.Is(ctx.Err(), context.DeadlineExceeded)
+		res.Canceled = !res.TimedOut
+	}
+	res.DurationMs = time.Since(start).Milliseconds()
+	res.Stdout, res.Stderr = stdout.buf.String(), stderr.buf.String()
+	res.Truncated = stdout.truncated || stderr.truncated
+
+	var exitErr *ssh.ExitError
+	var missing *ssh.ExitMissingError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		res.ExitCode = exitErr.ExitStatus()
+		res.Signal = exitErr.Signal()
+	case errors.As(err, &missing), res.TimedOut, res.Canceled:
+		res.ExitCode = -1
+	default:
+		return res, err
+	}
+	return res, nil
+}
+
+// execWriter keeps command output up to execOutputLimit and optionally streams it
+type execWriter struct {
+	tm        *TermManager
+	id        string
+	stream    string
+	emit      bool
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (w *execWriter) Write(p []byte) (int, error) {
+	if w.emit {
+		runtime.EventsEmit(w.tm.ctx, "exec:output:"+w.id, CommandOutput{ID: w.id, Stream: w.stream, Data: string(p)})
+	}
+	if room := execOutputLimit - w.buf.Len(); room < len(p) {
+		w.buf.Write(p[:max(room, 0)])
+		w.truncated = true
+	} else {
+		w.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+// profileParams loads a saved profile as connection parameters
+func (tm *TermManager) profileParams(id string) (SSHParams, error) {
+	b, err := os.ReadFile(store.HostsPath())
+	if err != nil {
+		return SSHParams{}, err
+	}
+	var hf hostsFile
+	if err := store.DecryptJSON(tm.masterKey, b, &hf); err != nil {
+		return SSHParams{}, err
+	}
+	hf.migrate()
+	for _, h := range hf.Hosts {
+		if h.ID == id {
+			if h.Kind != "" {
+				return SSHParams{}, fmt.Errorf("%s is a %s profile, not SSH", h.Name, h.Kind)
+			}
+			p := h.sshParams()
+			return p, p.normalize()
+		}
+	}
+	return SSHParams{}, errors.New("not found")
+}
+
+// sshParams converts a profile into the parameters StartSSH and the pool take,
+// before normalize
+func (h HostProfile) sshParams() SSHParams {
+	p := SSHParams{
+		Host: h.Host, Port: h.Port, Username: h.Username,
+		AuthType: h.Auth.Type, Password: h.Auth.Password, KeyPEM: h.Auth.KeyPEM,
+		Passphrase: h.Auth.Passphrase, CertPub: h.Auth.CertPub,
+		Cols: h.Cols, Rows: h.Rows, OutputEncoding: h.OutputEncoding, ScrollbackBytes: h.ScrollbackBytes, KeepAliveSec: h.KeepAliveSec, TimeoutSec: h.TimeoutSec,
+		AlgorithmPreset: h.AlgorithmPreset, KeyExchanges: h.KeyExchanges, Ciphers: h.Ciphers,
+		MACs: h.MACs, HostKeyAlgorithms: h.HostKeyAlgorithms,
+		ForwardAgent: h.ForwardAgent, AutoReconnect: h.AutoReconnect, ReconnectMaxTries: h.ReconnectMaxTries,
+		Env: h.Env, StartupCommands: h.StartupCommands, PromptPattern: h.PromptPattern,
+		Jumps: h.Jumps,
+	}
+	if h.Proxy != nil {
+		p.Proxy = *h.Proxy
+	}
+	return p
+}
@@ -0,0 +1,48 @@
+package main
+
+import "testing"
+
+func TestSSHParamsNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      SSHParams
+		port    int
+		keep    int
+		wantErr bool
+	}{
+		{"defaults", SSHParams{Host: "h", Username: "u"}, 22, 0, false},
+		{"explicit", SSHParams{Host: "h", Username: "u", Port: 2222, KeepAliveSec: 30, AutoReconnect: true}, 2222, 30, false},
+		{"reconnect keepalive", SSHParams{Host: "h", Username: "u", AutoReconnect: true}, 22, reconnectKeepAliveSec, false},
+		{"no host", SSHParams{Username: "u"}, 0, 0, true},
+		{"no user", SSHParams{Host: "h"}, 0, 0, true},
+		{"bad prompt", SSHParams{Host: "h", Username: "u", PromptPattern: "("}, 0, 0, true},
+	}
+	for _, tt := range tests {
+		p := tt.in
+		err := p.normalize()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (p.Port != tt.port || p.KeepAliveSec != tt.keep) {
+			t.Errorf("%s: port=%d keepalive=%d, want %d %d", tt.name, p.Port, p.KeepAliveSec, tt.port, tt.keep)
+		}
+	}
+}
+
+// A saved profile must share the pooled connection its open tab uses
+func TestProfileParamsMatchStartSSH(t *testing.T) {
+	tm := NewTermManager()
+	h := HostProfile{Host: "db.example.com", Username: "ops", Auth: AuthInfo{Type: "password", Password: "pw"}, AutoReconnect: true}
+	fromProfile := h.sshParams()
+	if err := fromProfile.normalize(); err != nil {
+		t.Fatal(err)
+	}
+	fromTab := SSHParams{Host: h.Host, Username: h.Username, AuthType: "password", Password: "pw", AutoReconnect: true, Cols: 80, Rows: 24}
+	if err := fromTab.normalize(); err != nil {
+		t.Fatal(err)
+	}
+	if tm.connKey(fromProfile) != tm.connKey(fromTab) {
+		t.Errorf("profile key %q != tab key %q", tm.connKey(fromProfile), tm.connKey(fromTab))
+	}
+}
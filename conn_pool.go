@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// sharedConn is one authenticated SSH connection (plus its ProxyJump chain) shared
+// by every shell, SFTP client and forward opened to the same host@user, like
+// OpenSSH's ControlMaster. It is closed when the last consumer releases it.
+type sharedConn struct {
+	key    string
+	label  string // key without credentials, used in logs
+	client *ssh.Client
+	jumps  []*ssh.Client // ProxyJump clients, outermost first
+	refs   int
+	ready  chan struct{} // closed once dialing finished
+	err    error         // dial error, valid after ready
+	closed chan struct{} // closed when the connection is torn down
+	dead   chan struct{} // closed once the transport is gone and the pool forgot it
+	done   bool          // set once torn down, guarded by TermManager.connMu
+
+	agentSource string          // agent served to forwarding requests, guarded by TermManager.connMu
+	remote      *remoteForwards // forwarded-tcpip dispatch, set by the first remote forward under TermManager.connMu
+}
+
+// connKey identifies connections that can be shared: same target, user and jump chain,
+// dialed the same way (proxy, algorithms, keepalive) with the same credentials
+func (tm *TermManager) connKey(p SSHParams) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s@%s:%d#%s", p.Username, p.Host, p.Port, authID(AuthInfo{
+		Type: p.AuthType, Password: p.Password, KeyPEM: p.KeyPEM, Passphrase: p.Passphrase, CertPub: p.CertPub,
+	}))
+	for _, j := range p.Jumps {
+		fmt.Fprintf(&b, "|%s@%s#%s", j.User, j.addr(), authID(j.Auth))
+	}
+	if proxy := tm.effectiveProxy(p.Proxy); proxy.enabled() {
+		fmt.Fprintf(&b, "|proxy=%s://%s@%s", proxy.Type, proxy.Username, proxy.addr())
+	}
+	if p.AlgorithmPreset != "" || len(p.KeyExchanges)+len(p.Ciphers)+len(p.MACs)+len(p.HostKeyAlgorithms) > 0 {
+		fmt.Fprintf(&b, "|algo=%s;%s;%s;%s;%s", p.AlgorithmPreset,
+			strings.Join(p.KeyExchanges, ","), strings.Join(p.Ciphers, ","),
+			strings.Join(p.MACs, ","), strings.Join(p.HostKeyAlgorithms, ","))
+	}
+	if p.KeepAliveSec > 0 {
+		fmt.Fprintf(&b, "|keepalive=%d", p.KeepAliveSec)
+	}
+	return b.String()
+}
+
+// connLabel names the target and jump chain of p for logs; unlike connKey it holds
+// nothing derived from credentials
+func connLabel(p SSHParams) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s@%s:%d", p.Username, p.Host, p.Port)
+	for _, j := range p.Jumps {
+		fmt.Fprintf(&b, "|%s@%s", j.User, j.addr())
+	}
+	return b.String()
+}
+
+// poolSecret keys authID, so equal credentials compare equal within this process
+// while the pool key gives nothing to brute-force offline
+var poolSecret = func() []byte {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return b
+}()
+
+// authID names a hop's credentials without revealing them
+func authID(a AuthInfo) string {
+	t := a.Type
+	if t == "" {
+		t = "password"
+	}
+	if t == "agent" {
+		return t
+	}
+	mac := hmac.New(sha256.New, poolSecret)
+	mac.Write([]byte(a.Password + "\x00" + a.KeyPEM + "\x00" + a.CertPub))
+	return t + ":" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// acquireConn returns a live shared connection for p, dialing one if needed.
+// Callers must balance it with releaseConn. When ctx ends first the caller gets
+// ctx's error and its reference is dropped once the dial settles.
+func (tm *TermManager) acquireConn(ctx context.Context, p SSHParams) (*sharedConn, error) {
+	key := tm.connKey(p)
+	tm.connMu.Lock()
+	c, ok := tm.conns[key]
+	if ok {
+		c.refs++
+	} else {
+		c = &sharedConn{key: key, label: connLabel(p), refs: 1, ready: make(chan struct{}), closed: make(chan struct{}), dead: make(chan struct{})}
+		tm.conns[key] = c
+		go tm.dialShared(c, p)
+	}
+	refs := c.refs
+	tm.connMu.Unlock()
+
+	select {
+	case <-c.ready:
+	case <-ctx.Done():
+		go func() {
+			<-c.ready
+			if c.err == nil {
+				tm.releaseConn(c)
+			}
+		}()
+		return nil, fmt.Errorf("connect %s: %w", c.label, ctx.Err())
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	if ok {
+		log.Printf("[Mux] reusing connection %s (%d consumers)", c.label, refs)
+	}
+	return c, nil
+}
+
+// dialShared dials c for p and closes c.ready once c.client or c.err is set
+func (tm *TermManager) dialShared(c *sharedConn, p SSHParams) {
+	c.client, c.jumps, c.err = tm.dialSSH(p)
+	if c.err != nil {
+		tm.connMu.Lock()
+		if tm.conns[c.key] == c {
+			delete(tm.conns, c.key)
+		}
+		tm.connMu.Unlock()
+		close(c.ready)
+		return
+	}
+	close(c.ready)
+
+	// forget the connection as soon as the transport dies so new tabs redial
+	go func() {
+		_ = c.client.Wait()
+		tm.connMu.Lock()
+		if tm.conns[c.key] == c {
+			delete(tm.conns, c.key)
+		}
+		tm.connMu.Unlock()
+		close(c.dead)
+	}()
+	if p.KeepAliveSec > 0 {
+		go keepAlive(c.client, time.Duration(p.KeepAliveSec)*time.Second, c.closed)
+	}
+}
+
+// retainConn adds a consumer to an already acquired connection
+func (tm *TermManager) retainConn(c *sharedConn) {
+	tm.connMu.Lock()
+	c.refs++
+	tm.connMu.Unlock()
+}
+
+// releaseConn drops a consumer and closes the connection when none are left
+func (tm *TermManager) releaseConn(c *sharedConn) {
+	tm.connMu.Lock()
+	c.refs--
+	last := c.refs <= 0 && !c.done
+	if last {
+		c.done = true
+		if tm.conns[c.key] == c {
+			delete(tm.conns, c.key)
+		}
+	}
+	tm.connMu.Unlock()
+	if !last {
+		return
+	}
+	log.Printf("[Mux] closing connection %s", c.label)
+	close(c.closed)
+	_ = c.client.Close()
+	closeChain(c.jumps)
+}
+
+// keepAlive pings the server every interval and closes the client when a ping
+// fails or goes unanswered for three intervals, so a dead peer is noticed quickly.
+func keepAlive(c *ssh.Client, interval time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			res := make(chan error, 1)
+			go func() {
+				_, _, err := c.SendRequest("keepalive@openssh.com", true, nil)
+				res <- err
+			}()
+			select {
+			case err := <-res:
+				if err != nil {
+					log.Printf("[Mux] keepalive failed, closing connection: %v", err)
+					_ = c.Close()
+					return
+				}
+			case <-time.After(3 * interval):
+				log.Printf("[Mux] no keepalive reply for %s, closing connection", 3*interval)
+				_ = c.Close()
+				return
+			case <-stop:
+				return
+			}
+		case <-stop:
+			return
+		}
+	}
+}
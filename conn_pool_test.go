@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConnKey(t *testing.T) {
+	tm := NewTermManager()
+	base := SSHParams{Host: "db.example.com", Port: 22, Username: "ops", AuthType: "password", Password: "hunter2"}
+	key := tm.connKey(base)
+
+	same := []func(*SSHParams){
+		func(p *SSHParams) {},
+		func(p *SSHParams) { p.Cols, p.Rows = 200, 50 },
+		func(p *SSHParams) { p.StartupCommands = []string{"cd /srv"} },
+		func(p *SSHParams) { p.Passphrase = "unused" },
+		func(p *SSHParams) { p.Proxy = ProxyConfig{Type: "none"} },
+	}
+	for i, mod := range same {
+		p := base
+		mod(&p)
+		if got := tm.connKey(p); got != key {
+			t.Errorf("same #%d: key %q differs from %q", i, got, key)
+		}
+	}
+
+	differ := map[string]func(*SSHParams){
+		"user":      func(p *SSHParams) { p.Username = "root" },
+		"host":      func(p *SSHParams) { p.Host = "db2.example.com" },
+		"port":      func(p *SSHParams) { p.Port = 2222 },
+		"password":  func(p *SSHParams) { p.Password = "other" },
+		"auth type": func(p *SSHParams) { p.AuthType, p.Password = "agent", "" },
+		"key":       func(p *SSHParams) { p.AuthType, p.KeyPEM = "key", "PEM" },
+		"jump":      func(p *SSHParams) { p.Jumps = []JumpHost{{Host: "bastion", User: "ops"}} },
+		"proxy":     func(p *SSHParams) { p.Proxy = ProxyConfig{Type: "socks5", Host: "127.0.0.1", Port: 1080} },
+		"preset":    func(p *SSHParams) { p.AlgorithmPreset = algoPresetLegacy },
+		"ciphers":   func(p *SSHParams) { p.Ciphers = []string{"aes128-ctr"} },
+		"host keys": func(p *SSHParams) { p.HostKeyAlgorithms = []string{"ssh-ed25519"} },
+		"keepalive": func(p *SSHParams) { p.KeepAliveSec = 15 },
+		"jump creds": func(p *SSHParams) {
+			p.Jumps = []JumpHost{{Host: "bastion", User: "ops", Auth: AuthInfo{Password: "x"}}}
+		},
+	}
+	seen := map[string]string{key: "base"}
+	for name, mod := range differ {
+		p := base
+		mod(&p)
+		got := tm.connKey(p)
+		if other, dup := seen[got]; dup {
+			t.Errorf("%s: key equals the one for %s: %q", name, other, got)
+		}
+		seen[got] = name
+	}
+	// jumps with different passwords must not share a connection either
+	a, b := base, base
+	a.Jumps = []JumpHost{{Host: "bastion", User: "ops", Auth: AuthInfo{Password: "x"}}}
+	b.Jumps = []JumpHost{{Host: "bastion", User: "ops", Auth: AuthInfo{Password: "y"}}}
+	if tm.connKey(a) == tm.connKey(b) {
+		t.Error("jump hops with different passwords share a key")
+	}
+}
+
+func TestConnKeyHidesCredentials(t *testing.T) {
+	tm := NewTermManager()
+	p := SSHParams{Host: "h", Port: 22, Username: "u", AuthType: "password", Password: "hunter2",
+		Jumps: []JumpHost{{Host: "j", User: "u", Auth: AuthInfo{Type: "key", KeyPEM: "-----BEGIN KEY-----"}}}}
+	for _, s := range []string{tm.connKey(p), connLabel(p)} {
+		if strings.Contains(s, "hunter2") || strings.Contains(s, "BEGIN") {
+			t.Errorf("credentials leak into %q", s)
+		}
+	}
+	if got, want := connLabel(p), "u@h:22|u@j:22"; got != want {
+		t.Errorf("connLabel = %q, want %q", got, want)
+	}
+}
+
+func TestReleaseConnKeepsSharedConnection(t *testing.T) {
+	tm := NewTermManager()
+	c := &sharedConn{key: "k", refs: 1, closed: make(chan struct{})}
+	tm.conns["k"] = c
+	tm.retainConn(c)
+	tm.releaseConn(c) // one consumer left, so the client must not be touched
+	if c.refs != 1 || c.done || tm.conns["k"] != c {
+		t.Errorf("after release: refs=%d done=%v pooled=%v, want 1 false true", c.refs, c.done, tm.conns["k"] == c)
+	}
+	select {
+	case <-c.closed:
+		t.Error("connection closed while still in use")
+	default:
+	}
+}
+
+func TestAcquireConnHonoursContext(t *testing.T) {
+	tm := NewTermManager()
+	p := SSHParams{Host: "h", Port: 22, Username: "u"}
+	// a dial that is still in progress
+	c := &sharedConn{key: tm.connKey(p), refs: 1, ready: make(chan struct{})}
+	tm.conns[c.key] = c
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := tm.acquireConn(ctx, p); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want DeadlineExceeded", err)
+	}
+	c.err = errors.New("dial failed")
+	close(c.ready)
+}
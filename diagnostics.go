@@ -0,0 +1,414 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// DiagStage is the outcome of one step of a connection test
+type DiagStage struct {
+	Name       string `json:"name"` // dns | tcp | version | kex | handshake | auth
+	OK         bool   `json:"ok"`
+	DurationMs int64  `json:"durationMs"`
+	Detail     string `json:"detail,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// DiagAlgorithms are the algorithms negotiated with a server
+type DiagAlgorithms struct {
+	KeyExchange string `json:"kex"`
+	HostKey     string `json:"hostKey"`
+	CipherOut   string `json:"cipherOut"` // client to server
+	CipherIn    string `json:"cipherIn"`  // server to client
+	MACOut      string `json:"macOut,omitempty"`
+	MACIn       string `json:"macIn,omitempty"`
+}
+
+// HopDiagnostics is the report for one gateway hop or the target
+type HopDiagnostics struct {
+	Label         string          `json:"label"`
+	Address       string          `json:"address"`
+	User          string          `json:"user"`
+	Stages        []DiagStage     `json:"stages"`
+	ServerVersion string          `json:"serverVersion,omitempty"`
+	Banner        string          `json:"banner,omitempty"`
+	HostKey       string          `json:"hostKey,omitempty"`       // type and SHA256 fingerprint
+	HostKeyStatus string          `json:"hostKeyStatus,omitempty"` // known | unknown | changed
+	Algorithms    *DiagAlgorithms `json:"algorithms,omitempty"`
+	AuthOffered   []string        `json:"authOffered,omitempty"`
+	AuthAttempted []string        `json:"authAttempted,omitempty"`
+	AuthSucceeded string          `json:"authSucceeded,omitempty"`
+	OK            bool            `json:"ok"`
+}
+
+// ConnectionReport is returned by TestConnection
+type ConnectionReport struct {
+	OK      bool             `json:"ok"`
+	Error   string           `json:"error,omitempty"`
+	Proxy   string           `json:"proxy,omitempty"`
+	TotalMs int64            `json:"totalMs"`
+	Hops    []HopDiagnostics `json:"hops"`
+}
+
+// TestConnection connects to p (through its jump chain) without opening a shell
+// and reports DNS, TCP, handshake and authentication results for every hop.
+// Connections are made outside the shared pool and closed before returning.
+func (tm *TermManager) TestConnection(p SSHParams) (ConnectionReport, error) {
+	if p.Host == "" || p.Username == "" {
+		return ConnectionReport{}, errors.New("host/username required")
+	}
+	if p.Port == 0 {
+		p.Port = 22
+	}
+	start := time.Now()
+	timeout := 10 * time.Second
+	if p.TimeoutSec > 0 {
+		timeout = time.Duration(p.TimeoutSec) * time.Second
+	}
+	proxy := tm.effectiveProxy(p.Proxy)
+	var report ConnectionReport
+	if proxy.enabled() {
+		report.Proxy = proxy.Type + "://" + proxy.addr()
+	}
+	algos := &ssh.ClientConfig{Timeout: timeout}
+	if err := applyAlgorithms(algos, p); err != nil {
+		report.Error = err.Error()
+		report.TotalMs = time.Since(start).Milliseconds()
+		return report, nil
+	}
+
+	hops := append([]JumpHost{}, p.Jumps...)
+	hops = append(hops, JumpHost{
+		Host: p.Host, Port: p.Port, User: p.Username,
+		Auth: AuthInfo{Type: p.AuthType, Password: p.Password, KeyPEM: p.KeyPEM, Passphrase: p.Passphrase, CertPub: p.CertPub},
+	})
+	var chain []*ssh.Client
+	defer func() { closeChain(chain) }()
+	var via *ssh.Client
+	for i, h := range hops {
+		label := "目标主机"
+		if i < len(hops)-1 {
+			label = fmt.Sprintf("跳板机 #%d", i+1)
+		}
+		hr, cli := tm.diagnoseHop(label, via, h, hopConfig(algos, h.User), proxy)
+		report.Hops = append(report.Hops, hr)
+		if cli == nil {
+			report.Error = fmt.Sprintf("%s 检测未通过", label)
+			break
+		}
+		chain = append(chain, cli)
+		via = cli
+	}
+	report.OK = report.Error == ""
+	report.TotalMs = time.Since(start).Milliseconds()
+	return report, nil
+}
+
+// diagnoseHop probes one hop: it first handshakes with auth callbacks that never
+// send credentials to learn which methods the server offers, then authenticates
+// for real on a second connection. On success the authenticated client is returned.
+func (tm *TermManager) diagnoseHop(label string, via *ssh.Client, h JumpHost, base *ssh.ClientConfig, proxy ProxyConfig) (HopDiagnostics, *ssh.Client) {
+	addr := h.addr()
+	hr := HopDiagnostics{Label: label, Address: addr, User: h.User}
+	stage := func(name string, t0 time.Time, detail string, err error) bool {
+		st := DiagStage{Name: name, OK: err == nil, DurationMs: time.Since(t0).Milliseconds(), Detail: detail}
+		if err != nil {
+			st.Error = err.Error()
+		}
+		hr.Stages = append(hr.Stages, st)
+		return err == nil
+	}
+
+	// DNS: only meaningful when we resolve the name ourselves
+	t0 := time.Now()
+	switch {
+	case via != nil:
+		stage("dns", t0, "由上一跳解析", nil)
+	case proxy.enabled():
+		stage("dns", t0, "由代理 "+proxy.addr()+" 解析", nil)
+	default:
+		ctx, cancel := context.WithTimeout(context.Background(), base.Timeout)
+		ips, err := net.DefaultResolver.LookupHost(ctx, h.Host)
+		cancel()
+		if !stage("dns", t0, strings.Join(ips, ", "), err) {
+			return hr, nil
+		}
+	}
+
+	dial := func() (net.Conn, error) {
+		if via != nil {
+			return via.Dial("tcp", addr)
+		}
+		return dialTCP(proxy, addr, base.Timeout)
+	}
+
+	// TCP connect, then a handshake whose auth callbacks only record what is offered
+	t0 = time.Now()
+	conn, err := dial()
+	if err != nil && via != nil && isForwardingProhibited(err) {
+		err = fmt.Errorf("%w (上一跳禁止端口转发，实际连接时会尝试 stdio 隧道)", err)
+	}
+	if !stage("tcp", t0, "", err) {
+		return hr, nil
+	}
+
+	var hostKey ssh.PublicKey
+	var offered []string
+	offer := func(m string) {
+		if !slices.Contains(offered, m) {
+			offered = append(offered, m)
+		}
+	}
+	probe := *withKnownHostKeyAlgorithms(base, addr)
+	probe.HostKeyCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		hostKey = key
+		return nil
+	}
+	probe.BannerCallback = func(msg string) error {
+		hr.Banner = strings.TrimSpace(msg)
+		return nil
+	}
+	errProbe := errors.New("probe")
+	probe.Auth = []ssh.AuthMethod{
+		ssh.PublicKeysCallback(func() ([]ssh.Signer, error) { offer("publickey"); return nil, nil }),
+		ssh.PasswordCallback(func() (string, error) { offer("password"); return "", errProbe }),
+		ssh.KeyboardInteractive(func(string, string, []string, []bool) ([]string, error) {
+			offer("keyboard-interactive")
+			return nil, errProbe
+		}),
+	}
+	tap := &wireTap{Conn: conn}
+	t0 = time.Now()
+	cconn, chans, reqs, err := ssh.NewClientConn(tap, addr, &probe)
+	_ = conn.Close()
+	// reported whatever the auth outcome, since failed auth is why diagnostics run
+	version, algs, ok, kexErr := tap.negotiated()
+	if version == "" {
+		stage("version", t0, "", errors.New("服务器未发送 SSH 标识"))
+	} else {
+		hr.ServerVersion = version
+		stage("version", t0, version, nil)
+	}
+	if ok && kexErr != nil {
+		stage("kex", t0, "", kexErr)
+	} else if ok {
+		hr.Algorithms = algs
+		stage("kex", t0, algs.KeyExchange+", "+algs.HostKey+", "+algs.CipherOut, nil)
+	}
+	if err == nil {
+		// the server let us in without credentials
+		_ = ssh.NewClient(cconn, chans, reqs).Close()
+		offer("none")
+	} else if hostKey == nil {
+		stage("handshake", t0, "", explainNegotiation(err))
+		return hr, nil
+	}
+	hr.AuthOffered = offered
+	hr.HostKey = hostKey.Type() + " " + ssh.FingerprintSHA256(hostKey)
+	hr.HostKeyStatus = hostKeyStatus(addr, hostKey)
+
+	// real connection with the profile's credentials
+	var attempted []string
+	trace := func(m string) {
+		if !slices.Contains(attempted, m) {
+			attempted = append(attempted, m)
+		}
+	}
+	methods, release, err := tm.tracedAuthMethods(h.Auth, h.Host, h.User, trace)
+	if err != nil {
+		stage("auth", time.Now(), "", err)
+		return hr, nil
+	}
+	defer release()
+	cfg := *withKnownHostKeyAlgorithms(base, addr)
+	cfg.Auth = methods
+	cfg.HostKeyCallback = tm.hostKeyCallback()
+
+	conn, err = dial()
+	if err != nil {
+		stage("handshake", time.Now(), "", err)
+		return hr, nil
+	}
+	t0 = time.Now()
+	cconn, chans, reqs, err = ssh.NewClientConn(conn, addr, &cfg)
+	hr.AuthAttempted = attempted
+	if err != nil {
+		_ = conn.Close()
+		stage("handshake", t0, hr.HostKey, nil)
+		stage("auth", t0, "尝试: "+strings.Join(attempted, ", "), explainNegotiation(err))
+		return hr, nil
+	}
+	hr.ServerVersion = string(cconn.ServerVersion())
+	if len(attempted) > 0 {
+		hr.AuthSucceeded = attempted[len(attempted)-1]
+	} else {
+		hr.AuthSucceeded = "none"
+	}
+	stage("handshake", t0, hr.ServerVersion, nil)
+	stage("auth", t0, hr.AuthSucceeded, nil)
+	hr.OK = true
+	return hr, ssh.NewClient(cconn, chans, reqs)
+}
+
+// wireTap records the start of both directions of a connection, so the server
+// identification line and the KEXINIT packets can be read back after a handshake
+// that failed authentication, when ssh.NewClientConn returns no metadata.
+type wireTap struct {
+	net.Conn
+	mu      sync.Mutex
+	in, out []byte
+}
+
+// wireTapLimit caps how much of each direction is kept; KEXINIT is a few KiB at most
+const wireTapLimit = 64 << 10
+
+func (t *wireTap) Read(b []byte) (int, error) {
+	n, err := t.Conn.Read(b)
+	t.mu.Lock()
+	if len(t.in) < wireTapLimit {
+		t.in = append(t.in, b[:min(n, wireTapLimit-len(t.in))]...)
+	}
+	t.mu.Unlock()
+	return n, err
+}
+
+func (t *wireTap) Write(b []byte) (int, error) {
+	t.mu.Lock()
+	if len(t.out) < wireTapLimit {
+		t.out = append(t.out, b[:min(len(b), wireTapLimit-len(t.out))]...)
+	}
+	t.mu.Unlock()
+	return t.Conn.Write(b)
+}
+
+// negotiated returns the server's identification line and the algorithms agreed on
+// by the captured KEXINIT packets. ok is false when either packet was not seen;
+// otherwise err names the first category without a common algorithm.
+func (t *wireTap) negotiated() (version string, algs *DiagAlgorithms, ok bool, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	version, serverKex := splitIdent(t.in)
+	_, clientKex := splitIdent(t.out)
+	server, sok := parseKexInit(serverKex)
+	client, cok := parseKexInit(clientKex)
+	if !sok || !cok {
+		return version, nil, false, nil
+	}
+	algs, err = negotiateKexInit(client, server)
+	return version, algs, true, err
+}
+
+// splitIdent splits captured bytes into the "SSH-" identification line, skipping any
+// lines sent before it (RFC 4253 4.2), and the binary packets that follow
+func splitIdent(b []byte) (string, []byte) {
+	for len(b) > 0 {
+		i := bytes.IndexByte(b, '\n')
+		if i < 0 {
+			return "", nil
+		}
+		line := strings.TrimRight(string(b[:i]), "\r")
+		b = b[i+1:]
+		if strings.HasPrefix(line, "SSH-") {
+			return line, b
+		}
+	}
+	return "", nil
+}
+
+// kexInit mirrors SSH_MSG_KEXINIT (RFC 4253 7.1) for ssh.Unmarshal
+type kexInit struct {
+	Cookie                  [16]byte `sshtype:"20"`
+	KexAlgos                []string
+	ServerHostKeyAlgos      []string
+	CiphersClientServer     []string
+	CiphersServerClient     []string
+	MACsClientServer        []string
+	MACsServerClient        []string
+	CompressionClientServer []string
+	CompressionServerClient []string
+	LanguagesClientServer   []string
+	LanguagesServerClient   []string
+	FirstKexFollows         bool
+	Reserved                uint32
+}
+
+// parseKexInit decodes the first, still unencrypted, binary packet of b as KEXINIT
+func parseKexInit(b []byte) (*kexInit, bool) {
+	if len(b) < 5 {
+		return nil, false
+	}
+	length, padding := int(binary.BigEndian.Uint32(b)), int(b[4])
+	if length < padding+1 || length > len(b)-4 {
+		return nil, false
+	}
+	var msg kexInit
+	if err := ssh.Unmarshal(b[5:4+length-padding], &msg); err != nil {
+		return nil, false
+	}
+	return &msg, true
+}
+
+// aeadCiphers carry their own integrity check, so no MAC is negotiated for them
+var aeadCiphers = []string{ssh.CipherAES128GCM, ssh.CipherAES256GCM, ssh.CipherChaCha20Poly1305}
+
+// negotiateKexInit applies the RFC 4253 rule: the first client algorithm the server
+// also supports wins. Fields agreed before a failure are kept in the result.
+func negotiateKexInit(client, server *kexInit) (*DiagAlgorithms, error) {
+	a := &DiagAlgorithms{}
+	pick := func(dst *string, c, s []string) bool {
+		for _, alg := range c {
+			if slices.Contains(s, alg) {
+				*dst = alg
+				return true
+			}
+		}
+		return false
+	}
+	fail := func(what string, s []string) (*DiagAlgorithms, error) {
+		return a, fmt.Errorf("没有共同的%s算法，服务器支持: %s", what, strings.Join(s, ", "))
+	}
+	if !pick(&a.KeyExchange, client.KexAlgos, server.KexAlgos) {
+		return fail("密钥交换", server.KexAlgos)
+	}
+	if !pick(&a.HostKey, client.ServerHostKeyAlgos, server.ServerHostKeyAlgos) {
+		return fail("主机密钥", server.ServerHostKeyAlgos)
+	}
+	if !pick(&a.CipherOut, client.CiphersClientServer, server.CiphersClientServer) {
+		return fail("加密", server.CiphersClientServer)
+	}
+	if !pick(&a.CipherIn, client.CiphersServerClient, server.CiphersServerClient) {
+		return fail("加密", server.CiphersServerClient)
+	}
+	if !slices.Contains(aeadCiphers, a.CipherOut) && !pick(&a.MACOut, client.MACsClientServer, server.MACsClientServer) {
+		return fail("MAC", server.MACsClientServer)
+	}
+	if !slices.Contains(aeadCiphers, a.CipherIn) && !pick(&a.MACIn, client.MACsServerClient, server.MACsServerClient) {
+		return fail("MAC", server.MACsServerClient)
+	}
+	return a, nil
+}
+
+// hostKeyStatus reports whether key matches known_hosts without prompting
+func hostKeyStatus(addr string, key ssh.PublicKey) string {
+	err := checkKnownHost(addr, remoteAddr(addr), key)
+	var ke *knownhosts.KeyError
+	switch {
+	case err == nil:
+		return "known"
+	case errors.As(err, &ke) && len(ke.Want) > 0:
+		return "changed"
+	default:
+		return "unknown"
+	}
+}
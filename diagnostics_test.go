@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// kexPacket frames k as an unencrypted binary packet with 4 bytes of padding
+func kexPacket(k *kexInit) []byte {
+	payload := ssh.Marshal(k)
+	b := binary.BigEndian.AppendUint32(nil, uint32(1+len(payload)+4))
+	b = append(b, 4)
+	b = append(b, payload...)
+	return append(b, 0, 0, 0, 0)
+}
+
+func testKexInit() *kexInit {
+	return &kexInit{
+		KexAlgos:            []string{"curve25519-sha256", "diffie-hellman-group14-sha256"},
+		ServerHostKeyAlgos:  []string{ssh.KeyAlgoED25519, ssh.KeyAlgoRSASHA256},
+		CiphersClientServer: []string{ssh.CipherAES128CTR}, CiphersServerClient: []string{ssh.CipherAES128CTR},
+		MACsClientServer: []string{ssh.HMACSHA256}, MACsServerClient: []string{ssh.HMACSHA256},
+		CompressionClientServer: []string{"none"}, CompressionServerClient: []string{"none"},
+	}
+}
+
+func TestSplitIdent(t *testing.T) {
+	tests := []struct {
+		in, ident, rest string
+	}{
+		{"SSH-2.0-OpenSSH_9.6\r\nPKT", "SSH-2.0-OpenSSH_9.6", "PKT"},
+		{"SSH-2.0-dropbear\nPKT", "SSH-2.0-dropbear", "PKT"},
+		{"welcome\r\nauthorized use only\r\nSSH-1.99-Cisco-1.25\r\n", "SSH-1.99-Cisco-1.25", ""},
+		{"SSH-2.0-cut", "", ""},
+		{"no banner\r\n", "", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		ident, rest := splitIdent([]byte(tt.in))
+		if ident != tt.ident || string(rest) != tt.rest {
+			t.Errorf("splitIdent(%q) = %q, %q; want %q, %q", tt.in, ident, rest, tt.ident, tt.rest)
+		}
+	}
+}
+
+func TestParseKexInit(t *testing.T) {
+	valid := kexPacket(testKexInit())
+	wrongType := kexPacket(testKexInit())
+	wrongType[5] = 21 // SSH_MSG_NEWKEYS
+	// framing that claims a payload cut off inside the first name-list
+	cut := binary.BigEndian.AppendUint32(nil, 1+17+4)
+	cut = append(append(append(cut, 0), valid[5:5+17+4]...), 0xff, 0xff)
+
+	tests := []struct {
+		name string
+		b    []byte
+		ok   bool
+	}{
+		{"valid", valid, true},
+		{"trailing packets", append(append([]byte{}, valid...), 0, 0, 0, 12), true},
+		{"empty", nil, false},
+		{"short header", valid[:3], false},
+		{"truncated", valid[:len(valid)-1], false},
+		{"padding exceeds length", []byte{0, 0, 0, 4, 8, 20, 0, 0, 0}, false},
+		{"huge length", []byte{0xff, 0xff, 0xff, 0xff, 4, 20, 0, 0, 0}, false},
+		{"wrong message", wrongType, false},
+		{"cut name-list", cut, false},
+		{"garbage", []byte(strings.Repeat("\x00\x00\x00\x10\x04garbage!", 3)), false},
+	}
+	for _, tt := range tests {
+		k, ok := parseKexInit(tt.b)
+		if ok != tt.ok {
+			t.Errorf("%s: ok = %v, want %v", tt.name, ok, tt.ok)
+		}
+		if ok && strings.Join(k.KexAlgos, ",") != "curve25519-sha256,diffie-hellman-group14-sha256" {
+			t.Errorf("%s: KexAlgos = %v", tt.name, k.KexAlgos)
+		}
+	}
+}
+
+func TestNegotiateKexInit(t *testing.T) {
+	tests := []struct {
+		name    string
+		mod     func(c, s *kexInit)
+		want    DiagAlgorithms
+		wantErr string
+	}{
+		{"client order wins", func(c, s *kexInit) {
+			s.KexAlgos = []string{"diffie-hellman-group14-sha256", "curve25519-sha256"}
+		}, DiagAlgorithms{KeyExchange: "curve25519-sha256", HostKey: ssh.KeyAlgoED25519,
+			CipherOut: ssh.CipherAES128CTR, CipherIn: ssh.CipherAES128CTR, MACOut: ssh.HMACSHA256, MACIn: ssh.HMACSHA256}, ""},
+		{"aead needs no MAC", func(c, s *kexInit) {
+			c.CiphersClientServer = []string{ssh.CipherChaCha20Poly1305}
+			s.CiphersClientServer = []string{ssh.CipherChaCha20Poly1305}
+			s.MACsClientServer = []string{"hmac-md5"}
+		}, DiagAlgorithms{KeyExchange: "curve25519-sha256", HostKey: ssh.KeyAlgoED25519,
+			CipherOut: ssh.CipherChaCha20Poly1305, CipherIn: ssh.CipherAES128CTR, MACIn: ssh.HMACSHA256}, ""},
+		{"no common kex", func(c, s *kexInit) {
+			s.KexAlgos = []string{"diffie-hellman-group1-sha1"}
+		}, DiagAlgorithms{}, "diffie-hellman-group1-sha1"},
+		{"no common cipher keeps earlier picks", func(c, s *kexInit) {
+			s.CiphersServerClient = []string{"3des-cbc"}
+		}, DiagAlgorithms{KeyExchange: "curve25519-sha256", HostKey: ssh.KeyAlgoED25519, CipherOut: ssh.CipherAES128CTR}, "3des-cbc"},
+		{"empty server lists", func(c, s *kexInit) { *s = kexInit{} }, DiagAlgorithms{}, "密钥交换"},
+	}
+	for _, tt := range tests {
+		c, s := testKexInit(), testKexInit()
+		tt.mod(c, s)
+		got, err := negotiateKexInit(c, s)
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.wantErr)
+		}
+		if got == nil || *got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWireTapNegotiated(t *testing.T) {
+	server := testKexInit()
+	server.CiphersClientServer = []string{ssh.CipherAES256CTR}
+	tap := &wireTap{
+		in:  append([]byte("SSH-2.0-OpenSSH_8.0\r\n"), kexPacket(server)...),
+		out: append([]byte("SSH-2.0-Go\r\n"), kexPacket(testKexInit())...),
+	}
+	version, _, ok, err := tap.negotiated()
+	if version != "SSH-2.0-OpenSSH_8.0" || !ok || err == nil {
+		t.Errorf("negotiated = %q, ok=%v, err=%v; want the server version and a cipher error", version, ok, err)
+	}
+
+	tap.in = tap.in[:30] // the connection dropped mid-KEXINIT
+	if _, _, ok, _ := tap.negotiated(); ok {
+		t.Error("a truncated KEXINIT was reported as seen")
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// socks5HandshakeTimeout bounds how long a SOCKS client may take to send its request
+const socks5HandshakeTimeout = 30 * time.Second
+
+// socksAuth is the username/password a dynamic forward requires (RFC 1929)
+type socksAuth struct {
+	username string
+	password string
+}
+
+// StartDynamicForward starts D-forward on a session: a SOCKS5 server on
+// bindHost:port whose CONNECT requests are dialed through SSH. When username
+// is set, clients must authenticate with it and password. Returns forward id.
+func (tm *TermManager) StartDynamicForward(id string, bindHost string, port int, username string, password string) (string, error) {
+	s, ok := tm.getSSH(id)
+	if !ok {
+		return "", errors.New("session not found")
+	}
+	if bindHost == "" {
+		bindHost = "127.0.0.1"
+	}
+	if len(username) > 255 || len(password) > 255 {
+		return "", errors.New("username/password too long")
+	}
+	laddr := net.JoinHostPort(bindHost, strconv.Itoa(port))
+	f := &portForward{id: fmt.Sprintf("fwd-%d", time.Now().UnixNano()), kind: forwardDynamic, laddr: laddr}
+	if username != "" {
+		f.auth = &socksAuth{username: username, password: password}
+	} else if ip := net.ParseIP(bindHost); ip == nil || !ip.IsLoopback() {
+		log.Printf("[Port Forward] WARNING: SOCKS5 server on %s accepts anyone who can reach it", laddr)
+	}
+	if err := tm.serveForward(s, f); err != nil {
+		return "", err
+	}
+	s.fwdMu.Lock()
+	s.forwards[f.id] = f
+	s.fwdMu.Unlock()
+	return f.id, nil
+}
+
+func (tm *TermManager) StopDynamicForward(id string, forwardId string) error {
+	return tm.stopForward(id, forwardId, forwardDynamic)
+}
+
+// socks5Serve runs the server side of a SOCKS5 handshake (RFC 1928) on c and
+// dials the requested target. Only CONNECT is supported.
+func socks5Serve(c net.Conn, auth *socksAuth, dial func(network, addr string) (net.Conn, error)) (string, net.Conn, error) {
+	_ = c.SetDeadline(time.Now().Add(socks5HandshakeTimeout))
+	addr, err := socks5Request(c, auth)
+	if err != nil {
+		return addr, nil, err
+	}
+	rc, err := dial("tcp", addr)
+	if err != nil {
+		code := byte(0x04) // host unreachable
+		if strings.Contains(err.Error(), "refused") {
+			code = 0x05
+		}
+		_ = socks5Answer(c, code)
+		return addr, nil, fmt.Errorf("dial %s: %w", addr, err)
+	}
+	if err := socks5Answer(c, 0x00); err != nil {
+		_ = rc.Close()
+		return addr, nil, err
+	}
+	_ = c.SetDeadline(time.Time{})
+	return addr, rc, nil
+}
+
+// socks5Request negotiates the method, checks credentials and reads the
+// CONNECT target
+func socks5Request(c net.Conn, auth *socksAuth) (string, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c, head[:]); err != nil {
+		return "", err
+	}
+	if head[0] != 0x05 {
+		return "", fmt.Errorf("unsupported SOCKS version %d", head[0])
+	}
+	methods := make([]byte, head[1])
+	if _, err := io.ReadFull(c, methods); err != nil {
+		return "", err
+	}
+	want := byte(0x00)
+	if auth != nil {
+		want = 0x02
+	}
+	if bytes.IndexByte(methods, want) < 0 {
+		_, _ = c.Write([]byte{0x05, 0xff})
+		return "", errors.New("client offers no acceptable authentication method")
+	}
+	if _, err := c.Write([]byte{0x05, want}); err != nil {
+		return "", err
+	}
+	if auth != nil {
+		if err := socks5CheckAuth(c, auth); err != nil {
+			return "", err
+		}
+	}
+
+	var req [4]byte
+	if _, err := io.ReadFull(c, req[:]); err != nil {
+		return "", err
+	}
+	if req[0] != 0x05 {
+		return "", fmt.Errorf("unsupported SOCKS version %d", req[0])
+	}
+	var host string
+	switch req[3] {
+	case 0x01, 0x04:
+		ip := make(net.IP, 4)
+		if req[3] == 0x04 {
+			ip = make(net.IP, 16)
+		}
+		if _, err := io.ReadFull(c, ip); err != nil {
+			return "", err
+		}
+		host = ip.String()
+	case 0x03:
+		var l [1]byte
+		if _, err := io.ReadFull(c, l[:]); err != nil {
+			return "", err
+		}
+		name := make([]byte, l[0])
+		if _, err := io.ReadFull(c, name); err != nil {
+			return "", err
+		}
+		host = string(name)
+	default:
+		_ = socks5Answer(c, 0x08)
+		return "", fmt.Errorf("unsupported address type %d", req[3])
+	}
+	var port [2]byte
+	if _, err := io.ReadFull(c, port[:]); err != nil {
+		return "", err
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port[:]))))
+	if req[1] != 0x01 {
+		_ = socks5Answer(c, 0x07)
+		return addr, fmt.Errorf("unsupported command %d", req[1])
+	}
+	return addr, nil
+}
+
+// socks5CheckAuth reads a username/password subnegotiation and answers it
+func socks5CheckAuth(c net.Conn, auth *socksAuth) error {
+	var ver [1]byte
+	if _, err := io.ReadFull(c, ver[:]); err != nil {
+		return err
+	}
+	if ver[0] != 0x01 {
+		return fmt.Errorf("unsupported authentication version %d", ver[0])
+	}
+	readField := func() ([]byte, error) {
+		var l [1]byte
+		if _, err := io.ReadFull(c, l[:]); err != nil {
+			return nil, err
+		}
+		b := make([]byte, l[0])
+		_, err := io.ReadFull(c, b)
+		return b, err
+	}
+	user, err := readField()
+	if err != nil {
+		return err
+	}
+	pass, err := readField()
+	if err != nil {
+		return err
+	}
+	userOK := subtle.ConstantTimeCompare(user, []byte(auth.username))
+	passOK := subtle.ConstantTimeCompare(pass, []byte(auth.password))
+	if userOK&passOK != 1 {
+		_, _ = c.Write([]byte{0x01, 0x01})
+		return fmt.Errorf("authentication failed for user %q", user)
+	}
+	_, err = c.Write([]byte{0x01, 0x00})
+	return err
+}
+
+// socks5Answer sends a reply with the given code. The bound address is left
+// zero: the real one lives on the SSH server and is not reported to us.
+func socks5Answer(c net.Conn, code byte) error {
+	_, err := c.Write([]byte{0x05, code, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+	return err
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestSocks5Request(t *testing.T) {
+	cat := func(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+	noAuth := []byte{0x05, 0x01, 0x00}
+	userPass := []byte{0x05, 0x01, 0x02}
+	creds := func(user, pass string) []byte {
+		return cat([]byte{0x01, byte(len(user))}, []byte(user), []byte{byte(len(pass))}, []byte(pass))
+	}
+	connect := func(atyp byte, addr []byte, port uint16) []byte {
+		return cat([]byte{0x05, 0x01, 0x00, atyp}, addr, []byte{byte(port >> 8), byte(port)})
+	}
+	failure := func(code byte) []byte { return []byte{0x05, code, 0x00, 0x01, 0, 0, 0, 0, 0, 0} }
+	auth := &socksAuth{username: "user", password: "secret"}
+
+	tests := []struct {
+		name    string
+		auth    *socksAuth
+		client  []byte
+		addr    string
+		wantErr bool
+		reply   []byte
+	}{
+		{
+			name:   "IPv4",
+			client: cat(noAuth, connect(0x01, []byte{10, 0, 0, 1}, 22)),
+			addr:   "10.0.0.1:22", reply: []byte{0x05, 0x00},
+		},
+		{
+			name:   "domain",
+			client: cat(noAuth, connect(0x03, cat([]byte{11}, []byte("example.com")), 443)),
+			addr:   "example.com:443", reply: []byte{0x05, 0x00},
+		},
+		{
+			name:   "IPv6",
+			client: cat(noAuth, connect(0x04, net.IPv6loopback, 8080)),
+			addr:   "[::1]:8080", reply: []byte{0x05, 0x00},
+		},
+		{
+			name:   "no-auth method among several",
+			client: cat([]byte{0x05, 0x02, 0x02, 0x00}, connect(0x01, []byte{127, 0, 0, 1}, 80)),
+			addr:   "127.0.0.1:80", reply: []byte{0x05, 0x00},
+		},
+		{
+			name:   "username and password",
+			auth:   auth,
+			client: cat(userPass, creds("user", "secret"), connect(0x01, []byte{127, 0, 0, 1}, 80)),
+			addr:   "127.0.0.1:80", reply: []byte{0x05, 0x02, 0x01, 0x00},
+		},
+		{
+			name:    "wrong password",
+			auth:    auth,
+			client:  cat(userPass, creds("user", "guess")),
+			wantErr: true, reply: []byte{0x05, 0x02, 0x01, 0x01},
+		},
+		{
+			name:    "auth required but not offered",
+			auth:    auth,
+			client:  noAuth,
+			wantErr: true, reply: []byte{0x05, 0xff},
+		},
+		{
+			name:    "auth offered but not configured",
+			client:  userPass,
+			wantErr: true, reply: []byte{0x05, 0xff},
+		},
+		{
+			name:    "SOCKS4 client",
+			client:  []byte{0x04, 0x01, 0x00, 0x50, 127, 0, 0, 1, 0x00},
+			wantErr: true,
+		},
+		{
+			name:    "BIND refused",
+			client:  cat(noAuth, []byte{0x05, 0x02, 0x00, 0x01, 10, 0, 0, 1, 0, 21}),
+			addr:    "10.0.0.1:21",
+			wantErr: true, reply: cat([]byte{0x05, 0x00}, failure(0x07)),
+		},
+		{
+			name:    "unknown address type",
+			client:  cat(noAuth, []byte{0x05, 0x01, 0x00, 0x05}),
+			wantErr: true, reply: cat([]byte{0x05, 0x00}, failure(0x08)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := net.Pipe()
+			go func() { _, _ = client.Write(tt.client) }()
+			replies := make(chan []byte)
+			go func() {
+				b, _ := io.ReadAll(client)
+				replies <- b
+			}()
+
+			addr, err := socks5Request(server, tt.auth)
+			_ = server.Close()
+			reply := <-replies
+			_ = client.Close()
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if addr != tt.addr {
+				t.Errorf("addr = %q, want %q", addr, tt.addr)
+			}
+			if !bytes.Equal(reply, tt.reply) {
+				t.Errorf("reply = %v, want %v", reply, tt.reply)
+			}
+		})
+	}
+}
@@ -73,7 +73,7 @@ func (fm *FileManager) startup(ctx context.Context) {
 // UploadFile 上传文件到远程服务器
 func (fm *FileManager) UploadFile(sessionID, localPath, remotePath string) (string, error) {
 	// 获取SSH会话
-	sess, ok := fm.tm.get(sessionID)
+	sess, ok := fm.tm.getSSH(sessionID)
 	if !ok {
 		return "", fmt.Errorf("会话不存在")
 	}
@@ -103,13 +103,19 @@ func (fm *FileManager) UploadFile(sessionID, localPath, remotePath string) (stri
 	fm.transfers[transferID] = transfer
 	fm.mu.Unlock()
 
+	// 传输期间持有共享连接，关闭标签页不会中断传输
+	conn := sess.sshConn()
+	fm.tm.retainConn(conn)
+
 	// 异步上传
-	go fm.doUpload(ctx, sess, transfer)
+	go fm.doUpload(ctx, conn, transfer)
 
 	return transferID, nil
 }
 
-func (fm *FileManager) doUpload(ctx context.Context, sess *sshSession, transfer *FileTransfer) {
+func (fm *FileManager) doUpload(ctx context.Context, conn *sharedConn, transfer *FileTransfer) {
+	defer fm.tm.releaseConn(conn)
+
 	// 发送初始进度
 	log.Printf("[FileTransfer] 开始上传: %s -> %s", transfer.LocalPath, transfer.RemotePath)
 	fm.emitProgress(transfer)
@@ -125,7 +131,7 @@ func (fm *FileManager) doUpload(ctx context.Context, sess *sshSession, transfer
 	}()
 
 	// 创建SFTP客户端
-	sftpClient, err := sftp.NewClient(sess.client)
+	sftpClient, err := sftp.NewClient(conn.client)
 	if err != nil {
 		transfer.Status = "failed"
 		transfer.Error = fmt.Sprintf("创建SFTP客户端失败: %v", err)
@@ -208,13 +214,13 @@ func (fm *FileManager) doUpload(ctx context.Context, sess *sshSession, transfer
 // DownloadFile 从远程服务器下载文件
 func (fm *FileManager) DownloadFile(sessionID, remotePath, localPath string) (string, error) {
 	// 获取SSH会话
-	sess, ok := fm.tm.get(sessionID)
+	sess, ok := fm.tm.getSSH(sessionID)
 	if !ok {
 		return "", fmt.Errorf("会话不存在")
 	}
 
 	// 创建SFTP客户端获取远程文件信息
-	sftpClient, err := sftp.NewClient(sess.client)
+	sftpClient, err := sftp.NewClient(sess.sshClient())
 	if err != nil {
 		return "", fmt.Errorf("创建SFTP客户端失败: %w", err)
 	}
@@ -249,13 +255,19 @@ func (fm *FileManager) DownloadFile(sessionID, remotePath, localPath string) (st
 	fm.transfers[transferID] = transfer
 	fm.mu.Unlock()
 
+	// 传输期间持有共享连接，关闭标签页不会中断传输
+	conn := sess.sshConn()
+	fm.tm.retainConn(conn)
+
 	// 异步下载
-	go fm.doDownload(ctx, sess, transfer)
+	go fm.doDownload(ctx, conn, transfer)
 
 	return transferID, nil
 }
 
-func (fm *FileManager) doDownload(ctx context.Context, sess *sshSession, transfer *FileTransfer) {
+func (fm *FileManager) doDownload(ctx context.Context, conn *sharedConn, transfer *FileTransfer) {
+	defer fm.tm.releaseConn(conn)
+
 	// 发送初始进度
 	log.Printf("[FileTransfer] 开始下载: %s -> %s", transfer.RemotePath, transfer.LocalPath)
 	fm.emitProgress(transfer)
@@ -271,7 +283,7 @@ func (fm *FileManager) doDownload(ctx context.Context, sess *sshSession, transfe
 	}()
 
 	// 创建SFTP客户端
-	sftpClient, err := sftp.NewClient(sess.client)
+	sftpClient, err := sftp.NewClient(conn.client)
 	if err != nil {
 		transfer.Status = "failed"
 		transfer.Error = fmt.Sprintf("创建SFTP客户端失败: %v", err)
@@ -421,13 +433,13 @@ func (fm *FileManager) emitProgress(transfer *FileTransfer) {
 // ListRemoteDir 列出远程目录内容
 func (fm *FileManager) ListRemoteDir(sessionID, remotePath string) ([]RemoteFile, error) {
 	// 获取SSH会话
-	sess, ok := fm.tm.get(sessionID)
+	sess, ok := fm.tm.getSSH(sessionID)
 	if !ok {
 		return nil, fmt.Errorf("会话不存在")
 	}
 
 	// 创建SFTP客户端
-	sftpClient, err := sftp.NewClient(sess.client)
+	sftpClient, err := sftp.NewClient(sess.sshClient())
 	if err != nil {
 		return nil, fmt.Errorf("创建SFTP客户端失败: %w", err)
 	}
@@ -472,12 +484,12 @@ func (fm *FileManager) ListRemoteDir(sessionID, remotePath string) ([]RemoteFile
 
 // GetRemotePwd 获取远程当前工作目录
 func (fm *FileManager) GetRemotePwd(sessionID string) (string, error) {
-	sess, ok := fm.tm.get(sessionID)
+	sess, ok := fm.tm.getSSH(sessionID)
 	if !ok {
 		return "", fmt.Errorf("会话不存在")
 	}
 
-	sftpClient, err := sftp.NewClient(sess.client)
+	sftpClient, err := sftp.NewClient(sess.sshClient())
 	if err != nil {
 		return "", fmt.Errorf("创建SFTP客户端失败: %w", err)
 	}
@@ -492,12 +504,12 @@ func (fm *FileManager) GetRemotePwd(sessionID string) (string, error) {
 
 // DeleteRemoteFile 删除远程文件或目录
 func (fm *FileManager) DeleteRemoteFile(sessionID, remotePath string) error {
-	sess, ok := fm.tm.get(sessionID)
+	sess, ok := fm.tm.getSSH(sessionID)
 	if !ok {
 		return fmt.Errorf("会话不存在")
 	}
 
-	sftpClient, err := sftp.NewClient(sess.client)
+	sftpClient, err := sftp.NewClient(sess.sshClient())
 	if err != nil {
 		return fmt.Errorf("创建SFTP客户端失败: %w", err)
 	}
@@ -517,12 +529,12 @@ func (fm *FileManager) DeleteRemoteFile(sessionID, remotePath string) error {
 
 // CreateRemoteDir 创建远程目录
 func (fm *FileManager) CreateRemoteDir(sessionID, remotePath string) error {
-	sess, ok := fm.tm.get(sessionID)
+	sess, ok := fm.tm.getSSH(sessionID)
 	if !ok {
 		return fmt.Errorf("会话不存在")
 	}
 
-	sftpClient, err := sftp.NewClient(sess.client)
+	sftpClient, err := sftp.NewClient(sess.sshClient())
 	if err != nil {
 		return fmt.Errorf("创建SFTP客户端失败: %w", err)
 	}
@@ -533,12 +545,12 @@ func (fm *FileManager) CreateRemoteDir(sessionID, remotePath string) error {
 
 // RenameRemoteFile 重命名远程文件或目录
 func (fm *FileManager) RenameRemoteFile(sessionID, oldPath, newPath string) error {
-	sess, ok := fm.tm.get(sessionID)
+	sess, ok := fm.tm.getSSH(sessionID)
 	if !ok {
 		return fmt.Errorf("会话不存在")
 	}
 
-	sftpClient, err := sftp.NewClient(sess.client)
+	sftpClient, err := sftp.NewClient(sess.sshClient())
 	if err != nil {
 		return fmt.Errorf("创建SFTP客户端失败: %w", err)
 	}
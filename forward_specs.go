@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ForwardSpec is a forward saved with a profile and started on every connect.
+// For remote forwards Bind is on the server and Target on this machine.
+type ForwardSpec struct {
+	Type       string `json:"type"` // local | remote | dynamic
+	BindHost   string `json:"bindHost,omitempty"`
+	BindPort   int    `json:"bindPort"`
+	TargetHost string `json:"targetHost,omitempty"` // not used by dynamic forwards
+	TargetPort int    `json:"targetPort,omitempty"`
+	// SOCKS5 credentials required by a dynamic forward, empty for none
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// ForwardFailure is the payload of forward:failed
+type ForwardFailure struct {
+	SessionID string      `json:"sessionId"`
+	Forward   ForwardSpec `json:"forward"` // without the password
+	Error     string      `json:"error"`
+}
+
+func (f ForwardSpec) validate() error {
+	switch f.Type {
+	case forwardLocal:
+		if f.BindPort <= 0 || f.TargetPort <= 0 {
+			return errors.New("local forward needs a local and a remote port")
+		}
+	case forwardRemote:
+		if f.TargetPort <= 0 {
+			return errors.New("remote forward needs a local target port")
+		}
+	case forwardDynamic:
+		if f.BindPort <= 0 {
+			return errors.New("dynamic forward needs a local port")
+		}
+	default:
+		return fmt.Errorf("unknown forward type: %s", f.Type)
+	}
+	if f.BindPort < 0 || f.BindPort > 65535 || f.TargetPort < 0 || f.TargetPort > 65535 {
+		return errors.New("port out of range")
+	}
+	return nil
+}
+
+// startForwardSpecs starts the saved forwards of a new session. A forward that
+// cannot start (port in use, refused by the server, ...) is reported on
+// forward:failed:<id> and forward:failed and does not affect the others.
+func (tm *TermManager) startForwardSpecs(id string, specs []ForwardSpec) {
+	for _, f := range specs {
+		err := f.validate()
+		if err == nil {
+			switch f.Type {
+			case forwardLocal:
+				_, err = tm.StartLocalForward(id, f.BindHost, f.BindPort, f.TargetHost, f.TargetPort)
+			case forwardRemote:
+				_, err = tm.StartRemoteForward(id, f.BindHost, f.BindPort, f.TargetHost, f.TargetPort)
+			case forwardDynamic:
+				_, err = tm.StartDynamicForward(id, f.BindHost, f.BindPort, f.Username, f.Password)
+			}
+		}
+		if err == nil {
+			continue
+		}
+		log.Printf("[Port Forward] session %s: saved %s forward on port %d failed: %v", id, f.Type, f.BindPort, err)
+		f.Password = ""
+		ev := ForwardFailure{SessionID: id, Forward: f, Error: err.Error()}
+		runtime.EventsEmit(tm.ctx, "forward:failed:"+id, ev)
+		runtime.EventsEmit(tm.ctx, "forward:failed", ev)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestForwardSpecValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		f       ForwardSpec
+		wantErr string
+	}{
+		{"local", ForwardSpec{Type: forwardLocal, BindPort: 8080, TargetHost: "db", TargetPort: 5432}, ""},
+		{"local without target port", ForwardSpec{Type: forwardLocal, BindPort: 8080}, "local forward"},
+		{"local without bind port", ForwardSpec{Type: forwardLocal, TargetPort: 80}, "local forward"},
+		{"remote with server-picked port", ForwardSpec{Type: forwardRemote, TargetPort: 3000}, ""},
+		{"remote without target port", ForwardSpec{Type: forwardRemote, BindPort: 9000}, "remote forward"},
+		{"dynamic", ForwardSpec{Type: forwardDynamic, BindPort: 1080, Username: "u", Password: "p"}, ""},
+		{"dynamic without port", ForwardSpec{Type: forwardDynamic}, "dynamic forward"},
+		{"bind port too large", ForwardSpec{Type: forwardDynamic, BindPort: 65536}, "out of range"},
+		{"target port too large", ForwardSpec{Type: forwardRemote, BindPort: 80, TargetPort: 70000}, "out of range"},
+		{"negative remote bind port", ForwardSpec{Type: forwardRemote, BindPort: -1, TargetPort: 80}, "out of range"},
+		{"unknown type", ForwardSpec{Type: "x11", BindPort: 6000}, "unknown forward type"},
+	}
+	for _, tt := range tests {
+		err := tt.f.validate()
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// forwardStatsInterval is how often forward:stats is emitted for sessions with forwards
+const forwardStatsInterval = 2 * time.Second
+
+// ForwardInfo describes a running forward for ListForwards and forward:stats
+type ForwardInfo struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`             // local | remote | dynamic
+	Bind        string `json:"bind"`             // listening address; on the server for remote forwards
+	Target      string `json:"target,omitempty"` // empty for dynamic forwards, whose clients pick the target
+	Listening   bool   `json:"listening"`        // false while the session reconnects
+	Active      int32  `json:"active"`           // open connections
+	Connections int64  `json:"connections"`      // connections accepted in total
+	BytesOut    int64  `json:"bytesOut"`         // sent towards the target
+	BytesIn     int64  `json:"bytesIn"`          // received from the target
+	LastError   string `json:"lastError,omitempty"`
+}
+
+// ForwardStats is the payload of forward:stats
+type ForwardStats struct {
+	SessionID string        `json:"sessionId"`
+	Forwards  []ForwardInfo `json:"forwards"`
+}
+
+// forwardStats counts the traffic of one forward
+type forwardStats struct {
+	active   atomic.Int32
+	conns    atomic.Int64
+	bytesOut atomic.Int64
+	bytesIn  atomic.Int64
+
+	mu      sync.Mutex
+	lastErr string
+}
+
+// fail records err unless it only reports a connection we closed ourselves
+func (s *forwardStats) fail(err error) {
+	if errors.Is(err, net.ErrClosed) || errors.Is(err, io.EOF) {
+		return
+	}
+	s.mu.Lock()
+	s.lastErr = err.Error()
+	s.mu.Unlock()
+}
+
+// countWriter adds the bytes written through it to n
+type countWriter struct {
+	w io.Writer
+	n *atomic.Int64
+}
+
+func (c countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// ListForwards returns the forwards of a session, oldest first
+func (tm *TermManager) ListForwards(sessionID string) ([]ForwardInfo, error) {
+	s, ok := tm.get(sessionID)
+	if !ok {
+		return nil, errors.New("session not found")
+	}
+	return s.forwardInfos(), nil
+}
+
+func (s *sshSession) forwardInfos() []ForwardInfo {
+	s.fwdMu.Lock()
+	defer s.fwdMu.Unlock()
+	out := make([]ForwardInfo, 0, len(s.forwards))
+	for _, f := range s.forwards {
+		f.stats.mu.Lock()
+		lastErr := f.stats.lastErr
+		f.stats.mu.Unlock()
+		out = append(out, ForwardInfo{
+			ID: f.id, Type: f.kind, Bind: f.laddr, Target: f.raddr, Listening: f.ln != nil,
+			Active:      f.stats.active.Load(),
+			Connections: f.stats.conns.Load(),
+			BytesOut:    f.stats.bytesOut.Load(),
+			BytesIn:     f.stats.bytesIn.Load(),
+			LastError:   lastErr,
+		})
+	}
+	// ids are fwd-<unix nanos>, so they sort by creation time
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// emitForwardStats periodically emits forward:stats:<id> and forward:stats for
+// every session that has forwards, until the app shuts down
+func (tm *TermManager) emitForwardStats() {
+	t := time.NewTicker(forwardStatsInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-tm.ctx.Done():
+			return
+		case <-t.C:
+		}
+		tm.mu.Lock()
+		sessions := make([]*sshSession, 0, len(tm.sessions))
+		for _, s := range tm.sessions {
+			sessions = append(sessions, s)
+		}
+		tm.mu.Unlock()
+		for _, s := range sessions {
+			infos := s.forwardInfos()
+			if len(infos) == 0 {
+				continue
+			}
+			ev := ForwardStats{SessionID: s.id, Forwards: infos}
+			runtime.EventsEmit(tm.ctx, "forward:stats:"+s.id, ev)
+			runtime.EventsEmit(tm.ctx, "forward:stats", ev)
+		}
+	}
+}
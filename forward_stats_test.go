@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+)
+
+func TestForwardStatsFail(t *testing.T) {
+	var s forwardStats
+	s.fail(io.EOF)
+	s.fail(fmt.Errorf("read: %w", net.ErrClosed))
+	if s.lastErr != "" {
+		t.Errorf("lastErr = %q after a normal close", s.lastErr)
+	}
+	s.fail(errors.New("connection reset by peer"))
+	if s.lastErr != "connection reset by peer" {
+		t.Errorf("lastErr = %q", s.lastErr)
+	}
+}
+
+func TestCountWriter(t *testing.T) {
+	var n atomic.Int64
+	var buf bytes.Buffer
+	w := countWriter{&buf, &n}
+	if _, err := io.Copy(w, bytes.NewReader(make([]byte, 100_000))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("abc")); err != nil {
+		t.Fatal(err)
+	}
+	if n.Load() != 100_003 || buf.Len() != 100_003 {
+		t.Errorf("counted %d, wrote %d, want 100003", n.Load(), buf.Len())
+	}
+}
+
+func TestForwardInfos(t *testing.T) {
+	s := &sshSession{forwards: map[string]*portForward{
+		"fwd-2": {id: "fwd-2", kind: forwardDynamic, laddr: "127.0.0.1:1080"},
+		"fwd-1": {id: "fwd-1", kind: forwardLocal, laddr: "127.0.0.1:8080", raddr: "db:5432", ln: dummyListener{}},
+	}}
+	f := s.forwards["fwd-1"]
+	f.stats.conns.Add(3)
+	f.stats.active.Add(1)
+	f.stats.bytesOut.Add(10)
+	f.stats.bytesIn.Add(20)
+	f.stats.fail(errors.New("refused"))
+
+	got := s.forwardInfos()
+	want := []ForwardInfo{
+		{ID: "fwd-1", Type: forwardLocal, Bind: "127.0.0.1:8080", Target: "db:5432", Listening: true,
+			Active: 1, Connections: 3, BytesOut: 10, BytesIn: 20, LastError: "refused"},
+		{ID: "fwd-2", Type: forwardDynamic, Bind: "127.0.0.1:1080"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("forwardInfos = %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("forward %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if _, err := NewTermManager().ListForwards("missing"); err == nil {
+		t.Error("ListForwards of an unknown session succeeded")
+	}
+}
+
+// dummyListener stands in for an open listener
+type dummyListener struct{}
+
+func (dummyListener) Accept() (net.Conn, error) { return nil, net.ErrClosed }
+func (dummyListener) Close() error              { return nil }
+func (dummyListener) Addr() net.Addr            { return &net.TCPAddr{} }
@@ -3,12 +3,15 @@ import './App.css'
 import TerminalTab from './components/TerminalTab'
 import WebPreviewTab from './components/WebPreviewTab'
 import FileBrowser from './components/FileBrowser'
-import { StartSSH, StartLocalForward } from '../wailsjs/go/main/TermManager'
+import { StartSSH, StartLocal, StartSerial, StartTelnet, StartLocalForward, TestConnection, ConfirmHostKey, AnswerKeyboardInteractive, CancelPrompt } from '../wailsjs/go/main/TermManager'
+import { EventsOn, EventsOff } from '../wailsjs/runtime'
 import Topbar from './components/Topbar'
 import Sidebar, { HostItem } from './components/Sidebar'
 import Modal from './components/Modal'
 import Settings from './components/Settings'
-import { SaveProfile, ListProfiles, GetProfile, ExportProfiles, ImportProfiles, Paths, DeleteProfile } from '../wailsjs/go/main/ProfilesManager'
+import ConnectionReportView from './components/ConnectionReport'
+import ConsoleConnect from './components/ConsoleConnect'
+import { SaveProfile, ListProfiles, GetProfile, ExportProfiles, ImportProfiles, Paths, DeleteProfile, PreviewSSHConfig, ImportSSHConfig, InspectCertificate } from '../wailsjs/go/main/ProfilesManager'
 import DockLayout, { LayoutData, TabData, BoxData } from 'rc-dock'
 import "rc-dock/dist/rc-dock.css";
 
@@ -22,6 +25,36 @@ type SSHParams = {
   Rows: number
 }
 
+type JumpHop = {
+  host: string
+  port: number
+  user: string
+  auth: { type: 'password'|'key'|'agent'; password?: string; key_pem?: string; passphrase?: string; cert_pub?: string }
+}
+
+const newJump = (): JumpHop => ({ host: '', port: 22, user: '', auth: { type: 'password' } })
+
+const splitList = (s: string) => s.split(',').map(t => t.trim()).filter(Boolean)
+const splitLines = (s: string) => s.split('\n').map(t => t.trim()).filter(Boolean)
+// KEY=VALUE per line <-> env map
+const parseEnv = (s: string) => {
+  const env: Record<string, string> = {}
+  for (const line of splitLines(s)) {
+    const i = line.indexOf('=')
+    if (i > 0) env[line.slice(0, i).trim()] = line.slice(i + 1)
+  }
+  return env
+}
+const formatEnv = (env?: Record<string, string>) => Object.entries(env || {}).map(([k, v]) => `${k}=${v}`).join('\n')
+// one-line summary of a saved forward rule
+const describeForward = (f: any) =>
+  f.type === 'dynamic' ? `D ${f.bindHost}:${f.bindPort} (SOCKS5)`
+  : f.type === 'remote' ? `R 远程 ${f.bindHost}:${f.bindPort || '自动'} → 本地 ${f.targetHost}:${f.targetPort}`
+  : `L 本地 ${f.bindHost}:${f.bindPort} → 远程 ${f.targetHost}:${f.targetPort}`
+
+// '' = use the global proxy from settings, 'none' = connect directly
+type ProxyCfg = { type: ''|'none'|'socks5'|'http'; host?: string; port?: number; username?: string; password?: string }
+
 function App() {
   const dockRef = useRef<DockLayout>(null)
   const [connectOpen, setConnectOpen] = useState(true)
@@ -38,24 +71,46 @@ function App() {
   const [username, setUsername] = useState('root')
   const [password, setPassword] = useState('')
   const [tags, setTags] = useState('')
-  const [authType, setAuthType] = useState<'password'|'key'>('password')
+  const [authType, setAuthType] = useState<'password'|'key'|'agent'>('password')
   const [keyPem, setKeyPem] = useState('')
   const [passphrase, setPassphrase] = useState('')
+  const [certPub, setCertPub] = useState('')
+  const [certInfo, setCertInfo] = useState<string>('')
+  useEffect(() => {
+    if (!certPub.trim()) { setCertInfo(''); return }
+    InspectCertificate(certPub).then(c => {
+      const until = c.validBefore ? new Date(c.validBefore).toLocaleString() : '永久'
+      const state = c.expired ? '❌ 已过期' : c.notYetValid ? '⏳ 尚未生效' : '✅ 有效'
+      setCertInfo(`${state} · 有效期至 ${until} · principals: ${(c.principals || []).join(', ') || '(任意)'} · ID: ${c.keyId}`)
+    }).catch((e: any) => setCertInfo('❌ ' + (e?.message || e)))
+  }, [certPub])
   const [keepAliveSec, setKeepAliveSec] = useState<number>(0)
   const [timeoutSec, setTimeoutSec] = useState<number>(10)
+  const [autoReconnect, setAutoReconnect] = useState<boolean>(false)
+  const [forwardAgent, setForwardAgent] = useState<''|'system'|'vault'>('')
+  const [reconnectMaxTries, setReconnectMaxTries] = useState<number>(0)
+  const [envText, setEnvText] = useState('')
+  const [startupText, setStartupText] = useState('')
+  const [promptPattern, setPromptPattern] = useState('')
+  const [outputEncoding, setOutputEncoding] = useState<''|'base64'>('')
+  const [scrollbackKB, setScrollbackKB] = useState<number>(0)
   const [cols, setCols] = useState<number>(120)
   const [rows, setRows] = useState<number>(30)
   const [showAdv, setShowAdv] = useState<boolean>(false)
   // ProxyJump
   const [useGateway, setUseGateway] = useState(false)
-  const [gwHost, setGwHost] = useState('')
-  const [gwPort, setGwPort] = useState<number>(22)
-  const [gwUser, setGwUser] = useState('')
-  const [gwAuth, setGwAuth] = useState<'password'|'key'>('password')
-  const [gwPassword, setGwPassword] = useState('')
-  const [gwKeyPem, setGwKeyPem] = useState('')
-  const [gwPassphrase, setGwPassphrase] = useState('')
-  // Tunnels (single rule - Local forward MVP)
+  const [jumps, setJumps] = useState<JumpHop[]>([])
+  const updateJump = (i: number, patch: Partial<JumpHop>) => setJumps(prev => prev.map((j, k) => k === i ? { ...j, ...patch } : j))
+  const updateJumpAuth = (i: number, patch: Partial<JumpHop['auth']>) => setJumps(prev => prev.map((j, k) => k === i ? { ...j, auth: { ...j.auth, ...patch } } : j))
+  const [proxy, setProxy] = useState<ProxyCfg>({ type: '' })
+  // SSH algorithm overrides (comma separated), for legacy devices
+  const [algoPreset, setAlgoPreset] = useState<''|'legacy'>('')
+  const [kexAlgos, setKexAlgos] = useState('')
+  const [cipherAlgos, setCipherAlgos] = useState('')
+  const [macAlgos, setMacAlgos] = useState('')
+  const [hostKeyAlgos, setHostKeyAlgos] = useState('')
+  // Tunnels saved with the profile and started by StartSSH; the tun* fields edit the next rule
+  const [forwards, setForwards] = useState<any[]>([])
   const [tunDir, setTunDir] = useState<'L'|'R'|'D'>('L')
   const [tunLHost, setTunLHost] = useState('127.0.0.1')
   const [tunLPort, setTunLPort] = useState<number>(0)
@@ -63,6 +118,12 @@ function App() {
   const [tunRPort, setTunRPort] = useState<number>(0)
   const [error, setError] = useState<string | null>(null)
   const [connecting, setConnecting] = useState(false)
+  const [testing, setTesting] = useState(false)
+  // serial/telnet console dialog; consoleProfile is the saved profile being edited or cloned
+  const [consoleOpen, setConsoleOpen] = useState(false)
+  const [consoleKind, setConsoleKind] = useState('serial')
+  const [consoleProfile, setConsoleProfile] = useState<any>(null)
+  const [diag, setDiag] = useState<any>(null)
 
   type Session = { id: string; title: string }
   const [sessions, setSessions] = useState<Session[]>([])
@@ -77,6 +138,9 @@ function App() {
     document.documentElement.setAttribute('data-theme', theme)
   }, [theme])
 
+  // a report is only meaningful for the form it was run against
+  useEffect(() => { if (!connectOpen) setDiag(null) }, [connectOpen])
+
   // Disable browser context menu globally (开发时可临时注释)
   useEffect(() => {
     const handleContextMenu = (e: MouseEvent) => {
@@ -212,6 +276,46 @@ function App() {
   }
   useEffect(() => { loadProfiles() }, [])
 
+  // 首次连接主机时确认主机指纹
+  useEffect(() => {
+    EventsOn('ssh:hostkey', (p: { id: string; host: string; keyType: string; fingerprint: string }) => {
+      const ok = window.confirm(
+        `🔐 首次连接主机 ${p.host}\n\n` +
+        `主机密钥类型: ${p.keyType}\n` +
+        `指纹: ${p.fingerprint}\n\n` +
+        `请确认该指纹与服务器一致。是否信任并继续连接？`
+      )
+      ConfirmHostKey(p.id, ok).catch(() => {})
+    })
+    return () => EventsOff('ssh:hostkey')
+  }, [])
+
+  // keyboard-interactive 认证（OTP/二次验证）
+  useEffect(() => {
+    EventsOn('ssh:kbd-interactive', (p: { id: string; host: string; user: string; name: string; instruction: string; questions: { prompt: string; echo: boolean }[] }) => {
+      const answers: string[] = []
+      for (const q of p.questions) {
+        const header = `🔑 ${p.user}@${p.host}` + (p.name ? ` - ${p.name}` : '') + (p.instruction ? `\n${p.instruction}` : '')
+        const a = window.prompt(`${header}\n\n${q.prompt}`)
+        if (a === null) {
+          CancelPrompt(p.id).catch(() => {})
+          return
+        }
+        answers.push(a)
+      }
+      AnswerKeyboardInteractive(p.id, answers).catch(() => {})
+    })
+    return () => EventsOff('ssh:kbd-interactive')
+  }, [])
+
+  // 自动启动的端口转发失败时提示，不影响已建立的连接
+  useEffect(() => {
+    EventsOn('forward:failed', (ev: { sessionId: string; forward: any; error: string }) => {
+      alert(`⚠️ 端口转发启动失败：${describeForward(ev.forward)}\n\n${ev.error}`)
+    })
+    return () => EventsOff('forward:failed')
+  }, [])
+
   // 强制阻止浮动窗口
   useEffect(() => {
     const checkAndCloseFloatBoxes = () => {
@@ -238,6 +342,124 @@ function App() {
     return () => clearInterval(interval)
   }, [])
 
+  // turn the tunnel fields into a saved rule; for R the remote side is where the server listens
+  function addForward() {
+    const lhost = tunLHost || '127.0.0.1', rhost = tunRHost || '127.0.0.1'
+    let f: any
+    if (tunDir === 'L') {
+      if (!(tunLPort > 0 && tunRPort > 0)) { setError('本地转发需要填写本地端口和远程端口'); return }
+      f = { type: 'local', bindHost: lhost, bindPort: tunLPort, targetHost: rhost, targetPort: tunRPort }
+    } else if (tunDir === 'R') {
+      if (!(tunLPort > 0)) { setError('远程转发需要填写本地端口'); return }
+      f = { type: 'remote', bindHost: rhost, bindPort: tunRPort, targetHost: lhost, targetPort: tunLPort }
+    } else {
+      if (!(tunLPort > 0)) { setError('动态转发需要填写本地端口'); return }
+      f = { type: 'dynamic', bindHost: lhost, bindPort: tunLPort }
+    }
+    setError(null)
+    setForwards(prev => [...prev, f])
+  }
+
+  // SSH parameters from the connect/edit form
+  function formParams(): SSHParams {
+    let p: SSHParams;
+    p = {
+        Host: host,
+        Port: Number(port) || 22,
+        Username: username,
+        Password: password,
+        AuthType: authType,
+        Cols: 0, 
+        Rows: 0,
+    } as any;
+    (p as any).KeyPEM = keyPem;
+    (p as any).Passphrase = passphrase;
+    (p as any).CertPub = certPub;
+    (p as any).KeepAliveSec = keepAliveSec;
+    (p as any).TimeoutSec = timeoutSec;
+    (p as any).AutoReconnect = autoReconnect;
+    (p as any).ForwardAgent = forwardAgent;
+    (p as any).ReconnectMaxTries = reconnectMaxTries;
+    (p as any).Env = parseEnv(envText);
+    (p as any).StartupCommands = splitLines(startupText);
+    (p as any).PromptPattern = promptPattern.trim();
+    (p as any).OutputEncoding = outputEncoding;
+    (p as any).ScrollbackBytes = scrollbackKB * 1024;
+    (p as any).Jumps = useGateway ? jumps : [];
+    (p as any).Proxy = proxy;
+    (p as any).Forwards = forwards;
+    (p as any).AlgorithmPreset = algoPreset;
+    (p as any).KeyExchanges = splitList(kexAlgos);
+    (p as any).Ciphers = splitList(cipherAlgos);
+    (p as any).MACs = splitList(macAlgos);
+    (p as any).HostKeyAlgorithms = splitList(hostKeyAlgos);
+    return p
+  }
+
+  async function testConnection() {
+    setTesting(true)
+    setDiag(null)
+    setError(null)
+    try {
+      setDiag(await TestConnection(formParams() as any))
+    } catch (e: any) {
+      setError('测试失败：' + (e?.message || String(e)))
+    } finally {
+      setTesting(false)
+    }
+  }
+
+  // register a session and show it as a terminal tab
+  function openTab(id: string, title: string) {
+    const s = { id, title }
+    setSessions((prev) => [...prev, s])
+    setActiveId(id)
+    
+    // Add to Dock
+    if (dockRef.current) {
+      const tab: TabData = {
+          id: id,
+          title: title,  // 简单的字符串，不需要手动添加关闭按钮
+          content: (
+              <TerminalTab 
+                  sessionId={id} 
+                  theme={theme} 
+                  active={true}
+                  onFocus={() => setActiveId(id)}
+              />
+          ),
+          closable: true,
+          cached: true,  // 缓存Tab内容，切换时不销毁
+          group: 'terminal-sessions'  // 确保使用正确的group配置
+      }
+      
+      try {
+        const currentLayout = dockRef.current.getLayout()
+        const firstPanel = currentLayout.dockbox?.children?.[0]
+        
+        if (firstPanel) {
+          dockRef.current.dockMove(tab, (firstPanel as any).id, 'middle')
+          console.log('✅ Tab added to dock:', tab.id)
+        } else {
+          console.error('❌ No panel found in dock')
+        }
+      } catch (err) {
+        console.error('❌ Error adding tab to dock:', err)
+      }
+    } else {
+      console.error('❌ dockRef is null!')
+    }
+  }
+
+  async function openLocal() {
+    try {
+      const id = await StartLocal({} as any)
+      openTab(id, '本地终端')
+    } catch (e: any) {
+      alert('❌ 无法启动本地终端：' + (e?.message || String(e)))
+    }
+  }
+
   async function connect(overrideParams?: any) {
     setConnecting(true)
     setError(null)
@@ -246,70 +468,14 @@ function App() {
       if (overrideParams) {
         p = overrideParams;
       } else {
-        p = {
-            Host: host,
-            Port: Number(port) || 22,
-            Username: username,
-            Password: password,
-            AuthType: authType,
-            Cols: 0, 
-            Rows: 0,
-        } as any;
-        (p as any).KeyPEM = keyPem;
-        (p as any).Passphrase = passphrase;
-        (p as any).KeepAliveSec = keepAliveSec;
-        (p as any).TimeoutSec = timeoutSec;
-        (p as any).GatewayHost = useGateway ? gwHost : '';
-        (p as any).GatewayPort = useGateway ? gwPort : 0;
-        (p as any).GatewayUser = useGateway ? gwUser : '';
-        (p as any).GatewayAuth = useGateway ? gwAuth : '';
-        (p as any).GatewayPassword = useGateway ? gwPassword : '';
-        (p as any).GatewayKeyPEM = useGateway ? gwKeyPem : '';
-        (p as any).GatewayPassphrase = useGateway ? gwPassphrase : '';
+        p = formParams()
       }
       
       const id = await StartSSH(p as any)
       const title = `${p.Username}@${p.Host}:${p.Port}`
       console.log('✅ SSH Session created:', id, title)
-      const s = { id, title }
-      setSessions((prev) => [...prev, s])
-      setActiveId(id)
+      openTab(id, title)
       setConnectOpen(false)
-      
-      // Add to Dock
-      if (dockRef.current) {
-        const tab: TabData = {
-            id: id,
-            title: title,  // 简单的字符串，不需要手动添加关闭按钮
-            content: (
-                <TerminalTab 
-                    sessionId={id} 
-                    theme={theme} 
-                    active={true}
-                    onFocus={() => setActiveId(id)}
-                />
-            ),
-            closable: true,
-            cached: true,  // 缓存Tab内容，切换时不销毁
-            group: 'terminal-sessions'  // 确保使用正确的group配置
-        }
-        
-        try {
-          const currentLayout = dockRef.current.getLayout()
-          const firstPanel = currentLayout.dockbox?.children?.[0]
-          
-          if (firstPanel) {
-            dockRef.current.dockMove(tab, (firstPanel as any).id, 'middle')
-            console.log('✅ Tab added to dock:', tab.id)
-          } else {
-            console.error('❌ No panel found in dock')
-          }
-        } catch (err) {
-          console.error('❌ Error adding tab to dock:', err)
-        }
-      } else {
-        console.error('❌ dockRef is null!')
-      }
 
       // save as profile if checked (or in edit mode) AND we are not using overrideParams (meaning manual connect)
       if ((saveCfg || editingHost) && !overrideParams) {
@@ -327,18 +493,27 @@ function App() {
             host,
             port: Number(port) || 22,
             username,
-            auth: { type: authType, password, key_pem: keyPem, passphrase },
+            auth: { type: authType, password, key_pem: keyPem, passphrase, cert_pub: certPub },
             keepAliveSec,
             timeoutSec,
+            autoReconnect,
+            forwardAgent,
+            reconnectMaxTries,
+            env: parseEnv(envText),
+            startupCommands: splitLines(startupText),
+            promptPattern: promptPattern.trim(),
+            outputEncoding,
+            scrollbackBytes: scrollbackKB * 1024,
             cols,
             rows,
-            gatewayHost: useGateway ? gwHost : '',
-            gatewayPort: useGateway ? gwPort : 0,
-            gatewayUser: useGateway ? gwUser : '',
-            gatewayAuth: useGateway ? gwAuth : '',
-            gatewayPassword: useGateway ? gwPassword : '',
-            gatewayKeyPEM: useGateway ? gwKeyPem : '',
-            gatewayPassphrase: useGateway ? gwPassphrase : '',
+            jumps: useGateway ? jumps : [],
+            proxy: proxy.type ? proxy : undefined,
+            forwards,
+            algorithmPreset: algoPreset,
+            keyExchanges: splitList(kexAlgos),
+            ciphers: splitList(cipherAlgos),
+            macs: splitList(macAlgos),
+            hostKeyAlgorithms: splitList(hostKeyAlgos),
             tags: tags ? tags.split(',').map(t => t.trim()).filter(t => t) : [],
           }
           await SaveProfile(profile)
@@ -346,11 +521,7 @@ function App() {
           setEditingHost(null) // 清除编辑状态
         } catch (e) { /* ignore save failure for now */ }
       }
-      
-      // start one local forward if configured
-      if (tunDir === 'L' && tunLPort > 0 && tunRPort > 0 && !overrideParams) {
-        try { await StartLocalForward(id, tunLHost || '127.0.0.1', Number(tunLPort), tunRHost || '127.0.0.1', Number(tunRPort)) } catch {}
-      }
+
     } catch (e: any) {
       const errorMsg = e?.message || String(e)
       setError(errorMsg)
@@ -364,7 +535,7 @@ function App() {
           suggestion += '2. 目标主机的用户名或密码错误\n'
           suggestion += '3. 服务器禁用了密码认证，需要使用私钥\n'
           suggestion += '\n请检查：\n'
-          suggestion += `- 跳板机: ${gwUser}@${gwHost}:${gwPort}\n`
+          jumps.forEach((j, i) => { suggestion += `- 跳板机 #${i + 1}: ${j.user}@${j.host}:${j.port || 22}\n` })
           suggestion += `- 目标主机: ${username}@${host}:${port}`
         } else {
           suggestion += '1. 用户名或密码错误\n'
@@ -372,9 +543,10 @@ function App() {
           suggestion += '3. 用户账号被锁定或不存在'
         }
       } else if (errorMsg.includes('禁止了端口转发') || errorMsg.includes('administratively prohibited')) {
-        suggestion = '\n\n🔧 跳板机禁止了端口转发功能\n\n'
+        suggestion = '\n\n🔧 跳板机禁止了端口转发功能，自动 stdio 隧道（nc / ssh -W）也未能建立\n\n'
+        suggestion += '💡 请确认跳板机上已安装 nc 或 ncat\n\n'
         suggestion += '📋 临时解决方案（两步连接）：\n'
-        suggestion += `1. 先连接到跳板机: ${gwUser}@${gwHost}\n`
+        suggestion += `1. 先连接到跳板机: ${jumps.map(j => `${j.user}@${j.host}`).join(' → ')}\n`
         suggestion += `2. 在跳板机终端中执行: ssh ${username}@${host}\n\n`
         suggestion += '🛠️ 永久解决方案（需要管理员）：\n'
         suggestion += '让管理员在跳板机修改 /etc/ssh/sshd_config：\n'
@@ -405,6 +577,12 @@ function App() {
     
     try {
       const p: any = await GetProfile(h.id)
+      if (p?.kind === 'serial' || p?.kind === 'telnet') {
+        setEditingHost(null)
+        setConsoleProfile(p)
+        setConsoleOpen(true)
+        return
+      }
       
       if (!p || !p.host || !p.username) {
         alert('配置加载失败或配置不完整')
@@ -420,18 +598,28 @@ function App() {
       setPassword(p.auth?.password || '')
       setKeyPem(p.auth?.key_pem || '')
       setPassphrase(p.auth?.passphrase || '')
+      setCertPub(p.auth?.cert_pub || '')
       setKeepAliveSec(p.keepAliveSec || 0)
       setTimeoutSec(p.timeoutSec || 10)
+      setAutoReconnect(!!p.autoReconnect)
+      setForwardAgent(p.forwardAgent || '')
+      setReconnectMaxTries(p.reconnectMaxTries || 0)
+      setEnvText(formatEnv(p.env))
+      setStartupText((p.startupCommands || []).join('\n'))
+      setPromptPattern(p.promptPattern || '')
+      setOutputEncoding(p.outputEncoding || '')
+      setScrollbackKB(Math.round((p.scrollbackBytes || 0) / 1024))
       setCols(p.cols || 120)
       setRows(p.rows || 30)
-      setUseGateway(!!p.gatewayHost)
-      setGwHost(p.gatewayHost || '')
-      setGwPort(p.gatewayPort || 22)
-      setGwUser(p.gatewayUser || '')
-      setGwAuth((p.gatewayAuth || 'password') as any)
-      setGwPassword(p.gatewayPassword || '')
-      setGwKeyPem(p.gatewayKeyPEM || '')
-      setGwPassphrase(p.gatewayPassphrase || '')
+      setUseGateway((p.jumps || []).length > 0)
+      setJumps(p.jumps || [])
+      setProxy(p.proxy || { type: '' })
+      setForwards(p.forwards || [])
+      setAlgoPreset(p.algorithmPreset || '')
+      setKexAlgos((p.keyExchanges || []).join(', '))
+      setCipherAlgos((p.ciphers || []).join(', '))
+      setMacAlgos((p.macs || []).join(', '))
+      setHostKeyAlgos((p.hostKeyAlgorithms || []).join(', '))
       setTags((p.tags || []).join(', '))
       
       // 打开对话框
@@ -449,6 +637,11 @@ function App() {
     
     try {
       const p: any = await GetProfile(h.id)
+      if (p?.kind === 'serial' || p?.kind === 'telnet') {
+        setConsoleProfile({ ...p, id: '', name: (p.name || '') + ' - 副本' })
+        setConsoleOpen(true)
+        return
+      }
       
       if (!p || !p.host || !p.username) {
         alert('配置加载失败或配置不完整')
@@ -464,18 +657,28 @@ function App() {
       setPassword(p.auth?.password || '')
       setKeyPem(p.auth?.key_pem || '')
       setPassphrase(p.auth?.passphrase || '')
+      setCertPub(p.auth?.cert_pub || '')
       setKeepAliveSec(p.keepAliveSec || 0)
       setTimeoutSec(p.timeoutSec || 10)
+      setAutoReconnect(!!p.autoReconnect)
+      setForwardAgent(p.forwardAgent || '')
+      setReconnectMaxTries(p.reconnectMaxTries || 0)
+      setEnvText(formatEnv(p.env))
+      setStartupText((p.startupCommands || []).join('\n'))
+      setPromptPattern(p.promptPattern || '')
+      setOutputEncoding(p.outputEncoding || '')
+      setScrollbackKB(Math.round((p.scrollbackBytes || 0) / 1024))
       setCols(p.cols || 120)
       setRows(p.rows || 30)
-      setUseGateway(!!p.gatewayHost)
-      setGwHost(p.gatewayHost || '')
-      setGwPort(p.gatewayPort || 22)
-      setGwUser(p.gatewayUser || '')
-      setGwAuth((p.gatewayAuth || 'password') as any)
-      setGwPassword(p.gatewayPassword || '')
-      setGwKeyPem(p.gatewayKeyPEM || '')
-      setGwPassphrase(p.gatewayPassphrase || '')
+      setUseGateway((p.jumps || []).length > 0)
+      setJumps(p.jumps || [])
+      setProxy(p.proxy || { type: '' })
+      setForwards(p.forwards || [])
+      setAlgoPreset(p.algorithmPreset || '')
+      setKexAlgos((p.keyExchanges || []).join(', '))
+      setCipherAlgos((p.ciphers || []).join(', '))
+      setMacAlgos((p.macs || []).join(', '))
+      setHostKeyAlgos((p.hostKeyAlgorithms || []).join(', '))
       setTags((p.tags || []).join(', '))
       
       // 打开对话框
@@ -494,6 +697,16 @@ function App() {
     
     try {
       const p: any = await GetProfile(h.id)
+      if (p?.kind === 'serial') {
+        const id = await StartSerial(p.serial)
+        openTab(id, p.name || p.serial.device)
+        return
+      }
+      if (p?.kind === 'telnet') {
+        const id = await StartTelnet(p.telnet)
+        openTab(id, p.name || `telnet:${p.telnet.host}`)
+        return
+      }
       
       if (!p || !p.host || !p.username) {
         alert('配置加载失败或配置不完整')
@@ -508,18 +721,28 @@ function App() {
       setPassword(p.auth?.password || '')
       setKeyPem(p.auth?.key_pem || '')
       setPassphrase(p.auth?.passphrase || '')
+      setCertPub(p.auth?.cert_pub || '')
       setKeepAliveSec(p.keepAliveSec || 0)
       setTimeoutSec(p.timeoutSec || 10)
+      setAutoReconnect(!!p.autoReconnect)
+      setForwardAgent(p.forwardAgent || '')
+      setReconnectMaxTries(p.reconnectMaxTries || 0)
+      setEnvText(formatEnv(p.env))
+      setStartupText((p.startupCommands || []).join('\n'))
+      setPromptPattern(p.promptPattern || '')
+      setOutputEncoding(p.outputEncoding || '')
+      setScrollbackKB(Math.round((p.scrollbackBytes || 0) / 1024))
       setCols(p.cols || 120)
       setRows(p.rows || 30)
-      setUseGateway(!!p.gatewayHost)
-      setGwHost(p.gatewayHost || '')
-      setGwPort(p.gatewayPort || 22)
-      setGwUser(p.gatewayUser || '')
-      setGwAuth((p.gatewayAuth || 'password') as any)
-      setGwPassword(p.gatewayPassword || '')
-      setGwKeyPem(p.gatewayKeyPEM || '')
-      setGwPassphrase(p.gatewayPassphrase || '')
+      setUseGateway((p.jumps || []).length > 0)
+      setJumps(p.jumps || [])
+      setProxy(p.proxy || { type: '' })
+      setForwards(p.forwards || [])
+      setAlgoPreset(p.algorithmPreset || '')
+      setKexAlgos((p.keyExchanges || []).join(', '))
+      setCipherAlgos((p.ciphers || []).join(', '))
+      setMacAlgos((p.macs || []).join(', '))
+      setHostKeyAlgos((p.hostKeyAlgorithms || []).join(', '))
       setTags((p.tags || []).join(', '))
       
       const params = {
@@ -531,15 +754,25 @@ function App() {
         Cols: 0, Rows: 0,
         KeyPEM: p.auth?.key_pem || '',
         Passphrase: p.auth?.passphrase || '',
+        CertPub: p.auth?.cert_pub || '',
         KeepAliveSec: p.keepAliveSec || 0,
         TimeoutSec: p.timeoutSec || 10,
-        GatewayHost: p.gatewayHost || '',
-        GatewayPort: p.gatewayPort || 22,
-        GatewayUser: p.gatewayUser || '',
-        GatewayAuth: p.gatewayAuth || 'password',
-        GatewayPassword: p.gatewayPassword || '',
-        GatewayKeyPEM: p.gatewayKeyPEM || '',
-        GatewayPassphrase: p.gatewayPassphrase || '',
+        AutoReconnect: !!p.autoReconnect,
+        ForwardAgent: p.forwardAgent || '',
+        ReconnectMaxTries: p.reconnectMaxTries || 0,
+        Env: p.env || {},
+        StartupCommands: p.startupCommands || [],
+        PromptPattern: p.promptPattern || '',
+        OutputEncoding: p.outputEncoding || '',
+        ScrollbackBytes: p.scrollbackBytes || 0,
+        Jumps: p.jumps || [],
+        Proxy: p.proxy || { type: '' },
+        Forwards: p.forwards || [],
+        AlgorithmPreset: p.algorithmPreset || '',
+        KeyExchanges: p.keyExchanges || [],
+        Ciphers: p.ciphers || [],
+        MACs: p.macs || [],
+        HostKeyAlgorithms: p.hostKeyAlgorithms || [],
       }
       await connect(params)
     } catch (e: any) {
@@ -592,15 +825,24 @@ function App() {
         Cols: 0, Rows: 0,
         KeyPEM: p.auth?.key_pem || '',
         Passphrase: p.auth?.passphrase || '',
+        CertPub: p.auth?.cert_pub || '',
         KeepAliveSec: p.keepAliveSec || 0,
         TimeoutSec: p.timeoutSec || 10,
-        GatewayHost: p.gatewayHost || '',
-        GatewayPort: p.gatewayPort || 22,
-        GatewayUser: p.gatewayUser || '',
-        GatewayAuth: p.gatewayAuth || 'password',
-        GatewayPassword: p.gatewayPassword || '',
-        GatewayKeyPEM: p.gatewayKeyPEM || '',
-        GatewayPassphrase: p.gatewayPassphrase || '',
+        AutoReconnect: !!p.autoReconnect,
+        ForwardAgent: p.forwardAgent || '',
+        ReconnectMaxTries: p.reconnectMaxTries || 0,
+        Env: p.env || {},
+        StartupCommands: p.startupCommands || [],
+        PromptPattern: p.promptPattern || '',
+        OutputEncoding: p.outputEncoding || '',
+        ScrollbackBytes: p.scrollbackBytes || 0,
+        Jumps: p.jumps || [],
+        Proxy: p.proxy || { type: '' },
+        AlgorithmPreset: p.algorithmPreset || '',
+        KeyExchanges: p.keyExchanges || [],
+        Ciphers: p.ciphers || [],
+        MACs: p.macs || [],
+        HostKeyAlgorithms: p.hostKeyAlgorithms || [],
       }
       
       console.log('🔒 Starting SSH connection for tunnel...')
@@ -769,6 +1011,26 @@ function App() {
     } catch (e:any) { alert('导入失败: ' + (e?.message||e)) }
   }
 
+  async function doImportSSHConfig() {
+    try {
+      const file = window.prompt('OpenSSH 配置文件路径（留空使用 ~/.ssh/config）', '')
+      if (file === null) return
+      const entries = await PreviewSSHConfig(file)
+      const fresh = entries.filter(e => !e.exists)
+      if (fresh.length === 0) { alert('没有可导入的新主机'); return }
+      const lines = entries.map(e => {
+        const p = e.profile
+        const via = (p.jumps || []).length ? ` (经 ${p.jumps.map(j => j.host).join(' → ')})` : ''
+        const head = `${e.exists ? '[已存在，跳过] ' : ''}${e.alias}: ${p.username}@${p.host}:${p.port}${via}`
+        return [head, ...(e.warnings || []).map(w => '    ⚠ ' + w)].join('\n')
+      })
+      if (!window.confirm(`将导入 ${fresh.length} 台主机：\n\n${lines.join('\n')}\n\n确认导入？`)) return
+      const added = await ImportSSHConfig(file, fresh.map(e => e.alias))
+      await loadProfiles()
+      alert(`导入完成，新增 ${added} 条`)
+    } catch (e:any) { alert('导入失败: ' + (e?.message||e)) }
+  }
+
   function toggleDevTools() {
     console.log('=== 开发者工具按钮被点击 ===')
     console.log('请使用以下方式打开开发者工具：')
@@ -794,9 +1056,12 @@ function App() {
       <Topbar
         connectOpen={connectOpen}
         onToggleConnect={() => setConnectOpen(v => !v)}
+        onOpenLocal={() => openLocal()}
+        onOpenConsole={(kind) => { setConsoleKind(kind); setConsoleProfile(null); setConsoleOpen(true) }}
         onToggleRecording={() => toggleRecording()}
         recording={isRec}
         onImport={doImport}
+        onImportSSHConfig={doImportSSHConfig}
         onExport={doExport}
         onSettings={() => setSettingsOpen(true)}
         theme={theme}
@@ -805,6 +1070,15 @@ function App() {
         onToggleFileTransfer={() => setShowFileTransfer(v => !v)}
       />
 
+      <ConsoleConnect
+        open={consoleOpen}
+        kind={consoleKind}
+        profile={consoleProfile}
+        onClose={() => setConsoleOpen(false)}
+        onOpened={(id, title) => openTab(id, title)}
+        onSaved={() => loadProfiles()}
+      />
+
       <Modal open={connectOpen} title={editingHost ? "✏️ 编辑主机" : "⚡ 新建连接"} onClose={() => { setConnectOpen(false); setEditingHost(null); }} width={860}
         footer={(
           <div style={{ display: 'flex', gap: 12, justifyContent: 'space-between', width: '100%', alignItems: 'center' }}>
@@ -818,6 +1092,9 @@ function App() {
             )}
             <div style={{ display: 'flex', gap: 8 }}>
               <button onClick={() => { setConnectOpen(false); setEditingHost(null); }}>取消</button>
+              <button onClick={() => testConnection()} disabled={testing || connecting || !host || !username}>
+                {testing ? '检测中...' : '🩺 测试连接'}
+              </button>
               <button onClick={() => connect()} disabled={connecting || !host || !username}>
                 {connecting ? '连接中...' : (editingHost ? '保存并连接' : '连接')}
               </button>
@@ -853,6 +1130,7 @@ function App() {
             <select value={authType} onChange={(e) => setAuthType(e.target.value as any)}>
               <option value="password">Password</option>
               <option value="key">Private Key</option>
+              <option value="agent">SSH Agent</option>
             </select>
           </label>
         </div>
@@ -863,7 +1141,7 @@ function App() {
               <input type="password" value={password} onChange={(e: React.ChangeEvent<HTMLInputElement>) => setPassword(e.target.value)} />
             </label>
           </div>
-        ) : (
+        ) : authType === 'key' ? (
           <div className="grid4" style={{ gap: 12, marginTop: 8 }}>
             <label style={{ gridColumn: 'span 3' }}>
               Private Key (PEM)
@@ -873,6 +1151,15 @@ function App() {
               Passphrase
               <input type="password" value={passphrase} onChange={(e: React.ChangeEvent<HTMLInputElement>) => setPassphrase(e.target.value)} />
             </label>
+            <label style={{ gridColumn: 'span 4' }}>
+              OpenSSH 证书（可选，id_*-cert.pub 内容）
+              <textarea value={certPub} onChange={(e) => setCertPub(e.target.value)} rows={2} style={{ width: '100%', resize: 'vertical', padding: 8 }} placeholder="ssh-ed25519-cert-v01@openssh.com AAAA..." />
+              {certInfo && <span style={{ fontSize: 12, color: 'var(--muted)' }}>{certInfo}</span>}
+            </label>
+          </div>
+        ) : (
+          <div style={{ marginTop: 8, fontSize: 12, color: 'var(--muted)' }}>
+            使用本机 ssh-agent（SSH_AUTH_SOCK 或 Windows OpenSSH Agent）中的密钥认证，私钥不会保存到 XGoTerm
           </div>
         )}
         <div style={{ marginTop: 8 }}>
@@ -896,6 +1183,111 @@ function App() {
               Rows
               <input type="number" value={rows} onChange={(e) => setRows(parseInt(e.target.value || '30'))} />
             </label>
+            <label>
+              Agent 转发
+              <select value={forwardAgent} onChange={(e) => setForwardAgent(e.target.value as ''|'system'|'vault')}>
+                <option value="">关闭</option>
+                <option value="system">本机 ssh-agent</option>
+                <option value="vault">本配置的私钥</option>
+              </select>
+            </label>
+            <label style={{ flexDirection: 'row', alignItems: 'center', gap: 8 }}>
+              <input type="checkbox" checked={autoReconnect} onChange={(e) => setAutoReconnect(e.target.checked)} />
+              断线自动重连
+            </label>
+            {autoReconnect && (
+              <label>
+                最大重试次数（0=不限）
+                <input type="number" value={reconnectMaxTries} onChange={(e) => setReconnectMaxTries(parseInt(e.target.value || '0'))} />
+              </label>
+            )}
+          </div>
+        )}
+        {showAdv && (
+          <div className="grid4" style={{ gap: 12, marginTop: 8 }}>
+            <label>
+              算法
+              <select value={algoPreset} onChange={(e) => setAlgoPreset(e.target.value as ''|'legacy')}>
+                <option value="">默认（安全算法）</option>
+                <option value="legacy">旧设备兼容（含 group1-sha1 / CBC / ssh-rsa）</option>
+              </select>
+            </label>
+            <label>
+              Kex
+              <input value={kexAlgos} onChange={(e) => setKexAlgos(e.target.value)} placeholder="留空使用默认，逗号分隔" />
+            </label>
+            <label>
+              Ciphers
+              <input value={cipherAlgos} onChange={(e) => setCipherAlgos(e.target.value)} placeholder="如 aes128-ctr, aes128-cbc" />
+            </label>
+            <label>
+              MACs
+              <input value={macAlgos} onChange={(e) => setMacAlgos(e.target.value)} placeholder="如 hmac-sha2-256, hmac-sha1" />
+            </label>
+            <label>
+              HostKey
+              <input value={hostKeyAlgos} onChange={(e) => setHostKeyAlgos(e.target.value)} placeholder="如 rsa-sha2-256, ssh-rsa" />
+            </label>
+          </div>
+        )}
+        {showAdv && (
+          <div className="grid4" style={{ gap: 12, marginTop: 8 }}>
+            <label>
+              环境变量（每行 KEY=VALUE，需服务器 AcceptEnv 允许）
+              <textarea rows={3} value={envText} onChange={(e) => setEnvText(e.target.value)} placeholder={'LANG=en_US.UTF-8\nTZ=Asia/Shanghai'} />
+            </label>
+            <label>
+              登录后执行（每行一条，按顺序）
+              <textarea rows={3} value={startupText} onChange={(e) => setStartupText(e.target.value)} placeholder={'sudo -i\ncd /srv/app'} />
+            </label>
+            <label>
+              提示符正则（可选，每条命令前等待匹配）
+              <input value={promptPattern} onChange={(e) => setPromptPattern(e.target.value)} placeholder="如 [$#] $" />
+            </label>
+            <label>
+              输出编码
+              <select value={outputEncoding} onChange={(e) => setOutputEncoding(e.target.value as ''|'base64')}>
+                <option value="">UTF-8 文本</option>
+                <option value="base64">原始字节（非 UTF-8 输出）</option>
+              </select>
+            </label>
+            <label>
+              回滚缓冲 KB（0=1024）
+              <input type="number" value={scrollbackKB} onChange={(e) => setScrollbackKB(parseInt(e.target.value || '0'))} />
+            </label>
+          </div>
+        )}
+        {showAdv && (
+          <div className="grid4" style={{ gap: 12, marginTop: 8 }}>
+            <label>
+              代理
+              <select value={proxy.type} onChange={(e) => setProxy({ ...proxy, type: e.target.value as ProxyCfg['type'] })}>
+                <option value="">使用全局设置</option>
+                <option value="none">直连</option>
+                <option value="socks5">SOCKS5</option>
+                <option value="http">HTTP CONNECT</option>
+              </select>
+            </label>
+            {(proxy.type === 'socks5' || proxy.type === 'http') && (
+              <>
+                <label>
+                  代理地址
+                  <input value={proxy.host || ''} onChange={(e) => setProxy({ ...proxy, host: e.target.value })} placeholder="proxy.example.com" />
+                </label>
+                <label>
+                  代理端口
+                  <input type="number" value={proxy.port || ''} onChange={(e) => setProxy({ ...proxy, port: parseInt(e.target.value || '0') })} placeholder={proxy.type === 'http' ? '8080' : '1080'} />
+                </label>
+                <label>
+                  代理用户名
+                  <input value={proxy.username || ''} onChange={(e) => setProxy({ ...proxy, username: e.target.value })} placeholder="可选" />
+                </label>
+                <label>
+                  代理密码
+                  <input type="password" value={proxy.password || ''} onChange={(e) => setProxy({ ...proxy, password: e.target.value })} placeholder="可选" />
+                </label>
+              </>
+            )}
           </div>
         )}
         {showAdv && (
@@ -917,7 +1309,10 @@ function App() {
                 <input 
                   type="checkbox" 
                   checked={useGateway} 
-                  onChange={(e) => setUseGateway(e.target.checked)}
+                  onChange={(e) => {
+                    setUseGateway(e.target.checked)
+                    if (e.target.checked && jumps.length === 0) setJumps([newJump()])
+                  }}
                   style={{ width: 18, height: 18, cursor: 'pointer' }}
                 />
                 <div>
@@ -944,7 +1339,7 @@ function App() {
                 }}>
                   <div style={{ fontWeight: 600, marginBottom: 8, color: 'var(--accent)' }}>📡 连接路径：</div>
                   <div style={{ lineHeight: 1.8, color: 'var(--text)' }}>
-                    [你的电脑] → [跳板机 {gwHost || '???'}] → [目标主机 {host || '???'}]
+                    [你的电脑] → {jumps.map((j, i) => `[跳板机 ${i + 1}: ${j.host || '???'}] → `).join('')}[目标主机 {host || '???'}]
                   </div>
                   <div style={{ fontSize: 11, color: 'var(--muted)', marginTop: 8 }}>
                     ✓ 先连接跳板机验证身份 → 再通过跳板机连接目标主机
@@ -975,57 +1370,74 @@ function App() {
               </>
             )}
 
-            {/* 跳板机配置表单 */}
-            {useGateway && (
-              <div style={{ display: 'flex', flexDirection: 'column', gap: 12 }}>
-                <div style={{ fontWeight: 600, color: 'var(--text)' }}>跳板机配置</div>
+            {/* 跳板机配置表单（按顺序逐跳连接） */}
+            {useGateway && jumps.map((j, i) => (
+              <div key={i} style={{ display: 'flex', flexDirection: 'column', gap: 12 }}>
+                <div style={{ display: 'flex', alignItems: 'center', gap: 8 }}>
+                  <div style={{ fontWeight: 600, color: 'var(--text)', flex: 1 }}>跳板机 #{i + 1}</div>
+                  <button onClick={() => setJumps(prev => prev.filter((_, k) => k !== i))}>移除</button>
+                </div>
                 <div className="grid4" style={{ gap: 12 }}>
                   <label>
                     跳板机地址
-                    <input placeholder="jump.example.com" value={gwHost} onChange={(e)=>setGwHost(e.target.value)} />
+                    <input placeholder="jump.example.com" value={j.host} onChange={(e)=>updateJump(i, { host: e.target.value })} />
                   </label>
                   <label>
                     端口
-                    <input type="number" placeholder="22" value={gwPort} onChange={(e)=>setGwPort(parseInt(e.target.value||'22'))} />
+                    <input type="number" placeholder="22" value={j.port} onChange={(e)=>updateJump(i, { port: parseInt(e.target.value||'22') })} />
                   </label>
                   <label>
                     用户名
-                    <input placeholder="root" value={gwUser} onChange={(e)=>setGwUser(e.target.value)} />
+                    <input placeholder="root" value={j.user} onChange={(e)=>updateJump(i, { user: e.target.value })} />
                   </label>
                   <label>
                     认证方式
-                    <select value={gwAuth} onChange={(e)=>setGwAuth(e.target.value as any)}>
+                    <select value={j.auth.type} onChange={(e)=>updateJumpAuth(i, { type: e.target.value as any })}>
                       <option value="password">密码</option>
                       <option value="key">私钥</option>
+                      <option value="agent">SSH Agent</option>
                     </select>
                   </label>
                 </div>
-                {gwAuth === 'password' ? (
+                {j.auth.type === 'password' ? (
                   <div className="grid4" style={{ gap:12 }}>
                     <label>
                       跳板机密码
-                      <input type="password" value={gwPassword} onChange={(e)=>setGwPassword(e.target.value)} />
+                      <input type="password" value={j.auth.password || ''} onChange={(e)=>updateJumpAuth(i, { password: e.target.value })} />
                     </label>
                   </div>
-                ) : (
+                ) : j.auth.type === 'key' ? (
                   <div className="grid4" style={{ gap:12 }}>
                     <label style={{ gridColumn: 'span 3' }}>
                       跳板机私钥 (PEM)
-                      <textarea rows={4} value={gwKeyPem} onChange={(e)=>setGwKeyPem(e.target.value)} style={{ width:'100%', resize:'vertical', padding:8 }} />
+                      <textarea rows={4} value={j.auth.key_pem || ''} onChange={(e)=>updateJumpAuth(i, { key_pem: e.target.value })} style={{ width:'100%', resize:'vertical', padding:8 }} />
                     </label>
                     <label>
                       私钥密码（可选）
-                      <input type="password" value={gwPassphrase} onChange={(e)=>setGwPassphrase(e.target.value)} />
+                      <input type="password" value={j.auth.passphrase || ''} onChange={(e)=>updateJumpAuth(i, { passphrase: e.target.value })} />
+                    </label>
+                    <label style={{ gridColumn: 'span 4' }}>
+                      OpenSSH 证书（可选）
+                      <textarea rows={2} value={j.auth.cert_pub || ''} onChange={(e)=>updateJumpAuth(i, { cert_pub: e.target.value })} style={{ width:'100%', resize:'vertical', padding:8 }} />
                     </label>
                   </div>
+                ) : (
+                  <div style={{ fontSize: 12, color: 'var(--muted)' }}>
+                    跳板机使用本机 ssh-agent 中的密钥认证
+                  </div>
                 )}
               </div>
+            ))}
+            {useGateway && (
+              <div>
+                <button onClick={() => setJumps(prev => [...prev, newJump()])}>+ 添加跳板机</button>
+              </div>
             )}
           </div>
         )}
         {showAdv && (
           <div style={{ display: 'flex', flexDirection: 'column', gap: 8, marginTop: 12 }}>
-            <div style={{ opacity: .8 }}>端口转发 / Tunnels（L/R/D）</div>
+            <div style={{ opacity: .8 }}>端口转发 / Tunnels（L/R/D，保存在配置中，连接后自动启动）</div>
             <div className="grid4" style={{ gap: 12 }}>
               <label>
                 Direction
@@ -1053,11 +1465,18 @@ function App() {
               </label>
             </div>
             <div>
-              <button disabled>添加规则（即将支持）</button>
+              <button onClick={addForward}>+ 添加规则</button>
             </div>
+            {forwards.map((f, i) => (
+              <div key={i} style={{ display: 'flex', gap: 8, alignItems: 'center', fontSize: 13 }}>
+                <span>{describeForward(f)}</span>
+                <button onClick={() => setForwards(prev => prev.filter((_, j) => j !== i))} title="删除规则">✕</button>
+              </div>
+            ))}
           </div>
         )}
         {error && <div style={{ color: 'salmon', marginTop: 10 }}>{error}</div>}
+        {diag && <ConnectionReportView report={diag} onClose={() => setDiag(null)} />}
       </Modal>
 
       <div style={{ display: 'flex', flex: 1, minHeight: 0 }}>
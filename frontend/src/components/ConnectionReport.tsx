@@ -0,0 +1,81 @@
+interface ConnectionReportProps {
+  report: any
+  onClose: () => void
+}
+
+const STAGE_NAMES: Record<string, string> = {
+  dns: 'DNS 解析',
+  tcp: 'TCP 连接',
+  version: '服务器标识',
+  kex: '算法协商',
+  handshake: 'SSH 握手',
+  auth: '身份认证',
+}
+
+const HOST_KEY_STATUS: Record<string, { text: string; color: string }> = {
+  known: { text: '已信任', color: '#8bc34a' },
+  unknown: { text: '未记录', color: '#ffb74d' },
+  changed: { text: '已变更!', color: 'salmon' },
+}
+
+// 连接测试报告：逐跳展示各阶段耗时与协商结果
+export default function ConnectionReport({ report, onClose }: ConnectionReportProps) {
+  const row = { display: 'flex', gap: 8, fontSize: 12, lineHeight: '20px' } as const
+  const label = { width: 90, opacity: 0.7, flexShrink: 0 } as const
+
+  return (
+    <div style={{ marginTop: 12, padding: 10, border: '1px solid #444', borderRadius: 4, background: '#1b1b1b' }}>
+      <div style={{ display: 'flex', justifyContent: 'space-between', marginBottom: 8 }}>
+        <b style={{ color: report.ok ? '#8bc34a' : 'salmon' }}>
+          {report.ok ? '✅ 连接测试通过' : '❌ 连接测试失败'}（{report.totalMs} ms）
+        </b>
+        <button onClick={onClose} style={{ padding: '0 8px' }}>×</button>
+      </div>
+      {report.proxy && <div style={row}><span style={label}>代理</span><span>{report.proxy}</span></div>}
+      {report.error && <div style={{ color: 'salmon', fontSize: 12, whiteSpace: 'pre-wrap', marginBottom: 6 }}>{report.error}</div>}
+
+      {(report.hops || []).map((h: any, i: number) => (
+        <div key={i} style={{ borderTop: '1px solid #333', paddingTop: 6, marginTop: 6 }}>
+          <div style={{ fontWeight: 600, marginBottom: 4 }}>
+            {h.ok ? '🟢' : '🔴'} {h.label} — {h.user}@{h.address}
+          </div>
+          {(h.stages || []).map((s: any, j: number) => (
+            <div key={j} style={row}>
+              <span style={label}>{s.ok ? '✔' : '✘'} {STAGE_NAMES[s.name] || s.name}</span>
+              <span style={{ width: 60, opacity: 0.7 }}>{s.durationMs} ms</span>
+              <span style={{ color: s.ok ? undefined : 'salmon', whiteSpace: 'pre-wrap', wordBreak: 'break-all' }}>
+                {s.error || s.detail}
+              </span>
+            </div>
+          ))}
+          {h.serverVersion && <div style={row}><span style={label}>服务器版本</span><span>{h.serverVersion}</span></div>}
+          {h.hostKey && (
+            <div style={row}>
+              <span style={label}>主机指纹</span>
+              <span style={{ wordBreak: 'break-all' }}>
+                {h.hostKey}{' '}
+                {HOST_KEY_STATUS[h.hostKeyStatus] && (
+                  <span style={{ color: HOST_KEY_STATUS[h.hostKeyStatus].color }}>({HOST_KEY_STATUS[h.hostKeyStatus].text})</span>
+                )}
+              </span>
+            </div>
+          )}
+          {h.algorithms && (
+            <div style={row}>
+              <span style={label}>协商算法</span>
+              <span style={{ wordBreak: 'break-all' }}>
+                kex {h.algorithms.kex} · hostkey {h.algorithms.hostKey} · cipher {h.algorithms.cipherOut}
+                {h.algorithms.cipherIn !== h.algorithms.cipherOut && ` / ${h.algorithms.cipherIn}`}
+                {h.algorithms.macOut && ` · mac ${h.algorithms.macOut}`}
+              </span>
+            </div>
+          )}
+          {h.authOffered && <div style={row}><span style={label}>服务器支持</span><span>{h.authOffered.join(', ')}</span></div>}
+          {h.authAttempted && <div style={row}><span style={label}>已尝试</span><span>{h.authAttempted.join(', ')}</span></div>}
+          {h.authSucceeded && <div style={row}><span style={label}>认证方式</span><span style={{ color: '#8bc34a' }}>{h.authSucceeded}</span></div>}
+          {h.banner && <pre style={{ fontSize: 11, opacity: 0.7, margin: '4px 0 0', whiteSpace: 'pre-wrap' }}>{h.banner}</pre>}
+        </div>
+      ))}
+    </div>
+  )
+}
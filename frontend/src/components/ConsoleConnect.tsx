@@ -0,0 +1,178 @@
+import { useEffect, useState } from 'react'
+import Modal from './Modal'
+import { StartSerial, StartTelnet, ListSerialPorts } from '../../wailsjs/go/main/TermManager'
+import { SaveProfile } from '../../wailsjs/go/main/ProfilesManager'
+
+interface Props {
+  open: boolean
+  // 'serial' | 'telnet', used for new connections; saved profiles carry their own kind
+  kind: string
+  // saved profile being edited or cloned, null for a new connection
+  profile: any | null
+  onClose: () => void
+  onOpened: (id: string, title: string) => void
+  onSaved: () => void
+}
+
+const BAUD_RATES = [1200, 2400, 4800, 9600, 19200, 38400, 57600, 115200, 230400, 460800, 921600]
+
+const DEFAULT_SERIAL = { device: '', baudRate: 9600, dataBits: 8, parity: 'none', stopBits: '1', flowControl: 'none' }
+
+const DEFAULT_TELNET = { host: '', port: 23, terminalType: '' }
+
+// 串口、Telnet 等非 SSH 控制台连接
+export default function ConsoleConnect({ open, kind: newKind, profile, onClose, onOpened, onSaved }: Props) {
+  const kind = profile?.kind || newKind
+  const [name, setName] = useState('')
+  const [tags, setTags] = useState('')
+  const [serial, setSerial] = useState<any>(DEFAULT_SERIAL)
+  const [telnet, setTelnet] = useState<any>(DEFAULT_TELNET)
+  const [ports, setPorts] = useState<string[]>([])
+  const [save, setSave] = useState(true)
+  const [busy, setBusy] = useState(false)
+  const [error, setError] = useState('')
+
+  useEffect(() => {
+    if (!open) return
+    setName(profile?.name || '')
+    setTags((profile?.tags || []).join(', '))
+    setSerial({ ...DEFAULT_SERIAL, ...(profile?.serial || {}) })
+    setTelnet({ ...DEFAULT_TELNET, ...(profile?.telnet || {}) })
+    setSave(true)
+    setError('')
+    if (kind === 'serial') ListSerialPorts().then(p => setPorts(p || [])).catch(() => setPorts([]))
+  }, [open, profile, kind])
+
+  const set = (k: string, v: any) => setSerial((s: any) => ({ ...s, [k]: v }))
+  const setT = (k: string, v: any) => setTelnet((t: any) => ({ ...t, [k]: v }))
+  const ready = kind === 'telnet' ? !!telnet.host : !!serial.device
+
+  async function connect() {
+    setBusy(true)
+    setError('')
+    try {
+      let id: string
+      if (kind === 'telnet') {
+        id = await StartTelnet(telnet)
+        onOpened(id, name || `telnet:${telnet.host}`)
+      } else {
+        id = await StartSerial(serial)
+        onOpened(id, name || `${serial.device}@${serial.baudRate}`)
+      }
+      if (save || profile?.id) {
+        try {
+          await SaveProfile({
+            id: profile?.id || '',
+            kind,
+            name: name || (kind === 'telnet' ? telnet.host : serial.device),
+            ...(kind === 'telnet' ? { telnet } : { serial }),
+            tags: tags.split(',').map(t => t.trim()).filter(Boolean),
+          } as any)
+          onSaved()
+        } catch { /* the session is open, saving is best effort */ }
+      }
+      onClose()
+    } catch (e: any) {
+      setError('连接失败：' + (e?.message || String(e)))
+    } finally {
+      setBusy(false)
+    }
+  }
+
+  const label = kind === 'telnet' ? 'Telnet 连接' : '串口连接'
+
+  return (
+    <Modal open={open} title={profile?.id ? `✏️ 编辑${label}` : `${kind === 'telnet' ? '📟' : '🔌'} ${label}`} onClose={onClose} width={640}
+      footer={(
+        <div style={{ display: 'flex', gap: 12, justifyContent: 'space-between', width: '100%', alignItems: 'center' }}>
+          {!profile?.id ? (
+            <label style={{ display: 'flex', alignItems: 'center', gap: 6 }}>
+              <input type="checkbox" checked={save} onChange={(e) => setSave(e.target.checked)} /> 保存为连接配置
+            </label>
+          ) : <span style={{ color: 'var(--muted)', fontSize: 14 }}>📝 编辑模式：修改将保存到配置</span>}
+          <div style={{ display: 'flex', gap: 8 }}>
+            <button onClick={onClose}>取消</button>
+            <button onClick={connect} disabled={busy || !ready}>{busy ? '连接中...' : '连接'}</button>
+          </div>
+        </div>
+      )}
+    >
+      <div className="grid4" style={{ gap: 12 }}>
+        <label style={{ gridColumn: 'span 2' }}>
+          名称
+          <input value={name} onChange={(e) => setName(e.target.value)} placeholder="例如：核心交换机 Console" />
+        </label>
+        <label style={{ gridColumn: 'span 2' }}>
+          分组标签（用逗号分隔）
+          <input value={tags} onChange={(e) => setTags(e.target.value)} placeholder="例如：网络,机房A" />
+        </label>
+      </div>
+      {kind === 'telnet' ? (
+      <div className="grid4" style={{ gap: 12, marginTop: 8 }}>
+        <label style={{ gridColumn: 'span 2' }}>
+          主机
+          <input value={telnet.host} onChange={(e) => setT('host', e.target.value)} placeholder="192.168.1.1" />
+        </label>
+        <label>
+          端口
+          <input type="number" value={telnet.port} onChange={(e) => setT('port', parseInt(e.target.value) || 23)} />
+        </label>
+        <label>
+          终端类型
+          <input value={telnet.terminalType} onChange={(e) => setT('terminalType', e.target.value)} placeholder="XTERM-256COLOR" />
+        </label>
+      </div>
+      ) : (<>
+      <div className="grid4" style={{ gap: 12, marginTop: 8 }}>
+        <label>
+          串口
+          <input list="serial-ports" value={serial.device} onChange={(e) => set('device', e.target.value)} placeholder="COM3" />
+          <datalist id="serial-ports">
+            {ports.map(p => <option key={p} value={p} />)}
+          </datalist>
+        </label>
+        <label>
+          波特率
+          <select value={serial.baudRate} onChange={(e) => set('baudRate', parseInt(e.target.value))}>
+            {BAUD_RATES.map(b => <option key={b} value={b}>{b}</option>)}
+          </select>
+        </label>
+        <label>
+          数据位
+          <select value={serial.dataBits} onChange={(e) => set('dataBits', parseInt(e.target.value))}>
+            {[8, 7, 6, 5].map(b => <option key={b} value={b}>{b}</option>)}
+          </select>
+        </label>
+        <label>
+          校验
+          <select value={serial.parity} onChange={(e) => set('parity', e.target.value)}>
+            <option value="none">无</option>
+            <option value="odd">奇校验</option>
+            <option value="even">偶校验</option>
+            <option value="mark">Mark</option>
+            <option value="space">Space</option>
+          </select>
+        </label>
+        <label>
+          停止位
+          <select value={serial.stopBits} onChange={(e) => set('stopBits', e.target.value)}>
+            <option value="1">1</option>
+            <option value="1.5">1.5</option>
+            <option value="2">2</option>
+          </select>
+        </label>
+        <label>
+          流控
+          <select value={serial.flowControl} onChange={(e) => set('flowControl', e.target.value)}>
+            <option value="none">无</option>
+            <option value="rtscts">RTS/CTS（硬件）</option>
+            <option value="xonxoff">XON/XOFF（软件）</option>
+          </select>
+        </label>
+      </div>
+      {ports.length === 0 && <div style={{ color: 'var(--muted)', fontSize: 12, marginTop: 8 }}>未检测到串口，请确认 USB 转串口驱动已安装</div>}
+      </>)}
+      {error && <div style={{ color: 'salmon', marginTop: 10 }}>{error}</div>}
+    </Modal>
+  )
+}
@@ -0,0 +1,64 @@
+import { useEffect, useState } from 'react'
+import { EventsOn, EventsOff } from '../../wailsjs/runtime'
+import { ListForwards, StopLocalForward, StopRemoteForward, StopDynamicForward } from '../../wailsjs/go/main/TermManager'
+import { formatBytes } from './TerminalTab'
+
+interface Props {
+  sessionId: string
+}
+
+const TYPE_LABELS: Record<string, string> = { local: 'L', remote: 'R', dynamic: 'D' }
+
+const STOP: Record<string, (id: string, fid: string) => Promise<void>> = {
+  local: StopLocalForward,
+  remote: StopRemoteForward,
+  dynamic: StopDynamicForward,
+}
+
+// 终端右下角的隧道面板，显示每条转发的连接数和流量
+export default function ForwardsBar({ sessionId }: Props) {
+  const [forwards, setForwards] = useState<any[]>([])
+
+  useEffect(() => {
+    const refresh = () => ListForwards(sessionId).then(f => setForwards(f || [])).catch(() => setForwards([]))
+    refresh()
+    const event = `forward:stats:${sessionId}`
+    EventsOn(event, (ev: any) => setForwards(ev?.forwards || []))
+    // stats are only emitted while forwards exist, so poll to notice the first one
+    const timer = setInterval(refresh, 5000)
+    return () => {
+      EventsOff(event)
+      clearInterval(timer)
+    }
+  }, [sessionId])
+
+  async function stop(f: any) {
+    try {
+      await STOP[f.type]?.(sessionId, f.id)
+    } catch { /* already gone */ }
+    setForwards(fs => fs.filter(x => x.id !== f.id))
+  }
+
+  if (forwards.length === 0) return null
+
+  return (
+    <div style={{
+      position: 'absolute', right: 12, bottom: 8, zIndex: 5,
+      display: 'flex', flexDirection: 'column', gap: 4,
+      fontSize: 12, background: 'var(--panel)', border: '1px solid var(--border)',
+      borderRadius: 6, padding: '6px 8px', opacity: 0.92,
+    }}>
+      {forwards.map(f => (
+        <div key={f.id} title={f.lastError ? '最近错误：' + f.lastError : ''} style={{ display: 'flex', gap: 8, alignItems: 'center' }}>
+          <span style={{ color: f.active > 0 ? 'var(--accent)' : 'var(--muted)' }}>●</span>
+          <span>{TYPE_LABELS[f.type] || f.type} {f.bind}{f.target ? ` → ${f.target}` : ' (SOCKS5)'}</span>
+          <span style={{ color: 'var(--muted)' }}>
+            {f.listening ? `${f.active} 个连接` : '重连中'} · ↑{formatBytes(f.bytesOut)} ↓{formatBytes(f.bytesIn)}
+          </span>
+          {f.lastError && <span style={{ color: 'salmon' }}>⚠</span>}
+          <button onClick={() => stop(f)} title="停止转发" style={{ padding: '0 6px' }}>✕</button>
+        </div>
+      ))}
+    </div>
+  )
+}
@@ -1,4 +1,5 @@
 import { useState, useEffect } from 'react'
+import { GetGlobalProxy, SetGlobalProxy } from '../../wailsjs/go/main/TermManager'
 
 interface SettingsProps {
   isOpen: boolean
@@ -25,6 +26,24 @@ const DEFAULT_SETTINGS: SettingsData = {
 
 export default function Settings({ isOpen, onClose }: SettingsProps) {
   const [settings, setSettings] = useState<SettingsData>(DEFAULT_SETTINGS)
+  // 全局代理保存在后端（加密存储，可能包含凭据）
+  const [proxy, setProxy] = useState<any>({ type: 'none' })
+  const [proxyMsg, setProxyMsg] = useState('')
+
+  useEffect(() => {
+    if (!isOpen) return
+    GetGlobalProxy().then(p => setProxy({ ...p, type: p.type || 'none' })).catch(() => {})
+    setProxyMsg('')
+  }, [isOpen])
+
+  const saveProxy = async () => {
+    try {
+      await SetGlobalProxy(proxy)
+      setProxyMsg('✓ 已保存，对新建连接生效')
+    } catch (e: any) {
+      setProxyMsg('保存失败: ' + (e?.message || e))
+    }
+  }
 
   // 从localStorage加载设置
   useEffect(() => {
@@ -181,6 +200,47 @@ export default function Settings({ isOpen, onClose }: SettingsProps) {
           </div>
         </div>
 
+        {/* 网络代理 */}
+        <div style={{ marginBottom: 24 }}>
+          <h3 style={{ fontSize: 16, marginBottom: 12, color: 'var(--text)' }}>网络代理</h3>
+          <label style={{ display: 'flex', alignItems: 'center', gap: 12, marginBottom: 12 }}>
+            <span style={{ minWidth: 100 }}>类型:</span>
+            <select
+              value={proxy.type}
+              onChange={(e) => setProxy({ ...proxy, type: e.target.value })}
+              style={{ flex: 1, padding: '6px 8px' }}
+            >
+              <option value="none">不使用代理</option>
+              <option value="socks5">SOCKS5</option>
+              <option value="http">HTTP CONNECT</option>
+            </select>
+          </label>
+          {proxy.type !== 'none' && (
+            <>
+              <label style={{ display: 'flex', alignItems: 'center', gap: 12, marginBottom: 12 }}>
+                <span style={{ minWidth: 100 }}>地址:</span>
+                <input value={proxy.host || ''} onChange={(e) => setProxy({ ...proxy, host: e.target.value })} style={{ flex: 1, padding: '4px 8px' }} />
+                <input type="number" value={proxy.port || ''} placeholder={proxy.type === 'http' ? '8080' : '1080'} onChange={(e) => setProxy({ ...proxy, port: parseInt(e.target.value) || 0 })} style={{ width: 80, padding: '4px 8px' }} />
+              </label>
+              <label style={{ display: 'flex', alignItems: 'center', gap: 12, marginBottom: 12 }}>
+                <span style={{ minWidth: 100 }}>用户名:</span>
+                <input value={proxy.username || ''} placeholder="可选" onChange={(e) => setProxy({ ...proxy, username: e.target.value })} style={{ flex: 1, padding: '4px 8px' }} />
+              </label>
+              <label style={{ display: 'flex', alignItems: 'center', gap: 12, marginBottom: 12 }}>
+                <span style={{ minWidth: 100 }}>密码:</span>
+                <input type="password" value={proxy.password || ''} placeholder="可选" onChange={(e) => setProxy({ ...proxy, password: e.target.value })} style={{ flex: 1, padding: '4px 8px' }} />
+              </label>
+            </>
+          )}
+          <div style={{ display: 'flex', alignItems: 'center', gap: 12 }}>
+            <button onClick={saveProxy}>保存代理设置</button>
+            <span style={{ fontSize: 12, color: 'var(--muted)' }}>{proxyMsg}</span>
+          </div>
+          <div style={{ fontSize: 12, color: 'var(--muted)', marginTop: 12, padding: 8, background: 'var(--panel2)', borderRadius: 4 }}>
+            💡 用于直连目标主机或第一个跳板机；主机配置中可单独指定代理或直连
+          </div>
+        </div>
+
         {/* 快捷键设置 - 预留 */}
         <div style={{ marginBottom: 24 }}>
           <h3 style={{ fontSize: 16, marginBottom: 12, color: 'var(--text)' }}>快捷键</h3>
@@ -3,8 +3,9 @@ import { Terminal } from 'xterm'
 import { FitAddon } from 'xterm-addon-fit'
 import 'xterm/css/xterm.css'
 import { EventsOn, EventsOff } from '../../wailsjs/runtime'
-import { Send, Resize } from '../../wailsjs/go/main/TermManager'
+import { Send, Resize, GetScrollback } from '../../wailsjs/go/main/TermManager'
 import { getSettings } from './Settings'
+import ForwardsBar from './ForwardsBar'
 
 interface Props {
   sessionId: string
@@ -13,6 +14,14 @@ interface Props {
   onFocus?: () => void
 }
 
+export function formatBytes(bytes: number) {
+  if (!bytes) return '0 B'
+  const k = 1024
+  const sizes = ['B', 'KB', 'MB', 'GB']
+  const i = Math.min(Math.floor(Math.log(bytes) / Math.log(k)), sizes.length - 1)
+  return Math.round(bytes / Math.pow(k, i) * 100) / 100 + ' ' + sizes[i]
+}
+
 // 主题配置
 function getThemeConfig(themeName: string) {
   const themes: Record<string, any> = {
@@ -269,22 +278,78 @@ export default function TerminalTab({ sessionId, theme, active, onFocus }: Props
 
     // 3. Setup Event Listeners
     // Data coming from backend
+    // Frames carry their end offset in the output stream as the second argument.
+    // Until the scrollback replay below finishes they are queued, afterwards
+    // frames already covered by the replay are dropped.
+    let replayed = -1
+    const queued: Array<[string | Uint8Array, number]> = []
+    const writeFrame = (data: string | Uint8Array, offset: number) => {
+        if (replayed < 0) { queued.push([data, offset]); return }
+        if (typeof offset === 'number' && offset <= replayed) return
+        term.write(data)
+    }
+    const decode64 = (b64: string) => {
+        const bin = atob(b64)
+        const bytes = new Uint8Array(bin.length)
+        for (let i = 0; i < bin.length; i++) bytes[i] = bin.charCodeAt(i)
+        return bytes
+    }
+
     const onData = (...args: any[]) => {
         // Wails sends the data as the first argument
         const payload = (args.length > 0 ? String(args[0]) : '')
         if (!payload) return
-        term.write(payload)
+        writeFrame(payload, args[1])
+    }
+
+    // base64 frames of sessions in binary output mode, written as raw bytes
+    const onData64 = (b64: string, offset: number) => {
+        if (!b64) return
+        writeFrame(decode64(b64), offset)
     }
 
+    // replay what the session printed before this view was mounted (e.g. after a reload)
+    GetScrollback(sessionId, 0).then(sb => {
+        if (sb.data) {
+            if (sb.truncated) term.writeln('\x1b[90m[较早的输出已超出回滚缓冲]\x1b[0m')
+            term.write(decode64(sb.data))
+        }
+        replayed = sb.next
+    }).catch(() => {
+        replayed = 0
+    }).finally(() => {
+        queued.splice(0).forEach(([data, offset]) => writeFrame(data, offset))
+    })
+
     // Backend events
     const dataEvent = `term:data:${sessionId}`
+    const data64Event = `term:data64:${sessionId}`
     const closedEvent = `term:closed:${sessionId}`
     const startedEvent = `term:started:${sessionId}`
+    const reconnectingEvent = `term:reconnecting:${sessionId}`
+    const reconnectedEvent = `term:reconnected:${sessionId}`
 
     EventsOn(dataEvent, onData)
-    EventsOn(closedEvent, () => term.writeln('\r\n[Session closed]'))
+    EventsOn(data64Event, onData64)
+    EventsOn(closedEvent, (ev: any) => {
+        // exit 0 / closed locally are normal, anything else is highlighted
+        const normal = !ev || ev.reason === 'closed' || (ev.reason === 'exit' && ev.exitCode === 0)
+        const color = normal ? '\x1b[90m' : '\x1b[31m'
+        const traffic = ev ? ` · ↓${formatBytes(ev.bytesIn)} ↑${formatBytes(ev.bytesOut)}` : ''
+        term.writeln(`\r\n${color}[Session closed${ev?.message ? ': ' + ev.message : ''}${traffic}]\x1b[0m`)
+    })
+    EventsOn(reconnectingEvent, (ev: any) => {
+        const wait = Math.round((ev?.delayMs || 0) / 1000)
+        const why = ev?.error ? ` (${ev.error})` : ''
+        term.writeln(`\r\n\x1b[33m[连接已断开，${wait}s 后第 ${ev?.attempt} 次重连${why}]\x1b[0m`)
+    })
+    EventsOn(reconnectedEvent, (ev: any) => {
+        term.writeln('\r\n\x1b[32m[已重新连接]\x1b[0m')
+        if (ev?.failedForwards?.length) term.writeln(`\x1b[31m[以下端口转发无法恢复: ${ev.failedForwards.join(', ')}]\x1b[0m`)
+        handleResize()
+    })
     
-    EventsOn(startedEvent, () => {
+    EventsOn(startedEvent, (ev: any) => {
         console.log('✅ Terminal ready:', sessionId)
         startedRef.current = true
         setIsReady(true) // Remove loading spinner
@@ -292,8 +357,8 @@ export default function TerminalTab({ sessionId, theme, active, onFocus }: Props
         // Force a fit/resize sync now that session is officially "started"
         handleResize()
         
-        // Trigger a prompt refresh just in case
-        void Send(sessionId, '\r').catch(() => {})
+        // Trigger a prompt refresh just in case, unless the backend is typing startup commands
+        if (!ev?.startupCommands) void Send(sessionId, '\r').catch(() => {})
     })
 
     // User Input
@@ -340,8 +405,11 @@ export default function TerminalTab({ sessionId, theme, active, onFocus }: Props
         ro.disconnect()
         containerRef.current?.removeEventListener('mousedown', handleMiddleClick)
         EventsOff(dataEvent)
+        EventsOff(data64Event)
         EventsOff(closedEvent)
         EventsOff(startedEvent)
+        EventsOff(reconnectingEvent)
+        EventsOff(reconnectedEvent)
         term.dispose()
         termRef.current = undefined
         fitRef.current = undefined
@@ -396,6 +464,7 @@ export default function TerminalTab({ sessionId, theme, active, onFocus }: Props
         </div>
       )}
       <div ref={containerRef} className="terminal-container" style={{ width: '100%', height: '100%' }} />
+      <ForwardsBar sessionId={sessionId} />
     </div>
   )
 }
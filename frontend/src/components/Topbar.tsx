@@ -3,9 +3,12 @@ import React from 'react'
 interface Props {
   connectOpen: boolean
   onToggleConnect: () => void
+  onOpenLocal: () => void
+  onOpenConsole: (kind: 'serial' | 'telnet') => void
   onToggleRecording: () => void
   recording: boolean
   onImport: () => void
+  onImportSSHConfig: () => void
   onExport: () => void
   onSettings: () => void
   theme: 'dark' | 'light'
@@ -14,7 +17,7 @@ interface Props {
   onToggleFileTransfer?: () => void
 }
 
-export default function Topbar({ connectOpen, onToggleConnect, onToggleRecording, recording, onImport, onExport, onSettings, theme, onToggleTheme, onToggleDevTools, onToggleFileTransfer }: Props) {
+export default function Topbar({ connectOpen, onToggleConnect, onOpenLocal, onOpenConsole, onToggleRecording, recording, onImport, onImportSSHConfig, onExport, onSettings, theme, onToggleTheme, onToggleDevTools, onToggleFileTransfer }: Props) {
   return (
     <div className="topbar">
       <strong style={{ display: 'flex', alignItems: 'center', gap: 8 }}>
@@ -25,6 +28,15 @@ export default function Topbar({ connectOpen, onToggleConnect, onToggleRecording
       <button onClick={onToggleConnect} style={{ background: 'var(--accent)', color: '#fff', borderColor: 'transparent' }}>
         {connectOpen ? '关闭面板' : '⚡ 新建连接'}
       </button>
+      <button onClick={onOpenLocal} title="在本机打开终端">
+        💻 本地
+      </button>
+      <button onClick={() => onOpenConsole('serial')} title="串口控制台">
+        🔌 串口
+      </button>
+      <button onClick={() => onOpenConsole('telnet')} title="Telnet 连接">
+        📟 Telnet
+      </button>
       <button onClick={onToggleFileTransfer} title="文件传输">
         📁 文件
       </button>
@@ -35,6 +47,7 @@ export default function Topbar({ connectOpen, onToggleConnect, onToggleRecording
         </button>
         <div style={{ width: 1, height: 20, background: 'var(--border)', margin: '0 4px' }} />
         <button className="icon-btn" onClick={onImport} title="导入配置">📥</button>
+        <button className="icon-btn" onClick={onImportSSHConfig} title="从 ~/.ssh/config 导入">🗝️</button>
         <button className="icon-btn" onClick={onExport} title="导出配置">📤</button>
         <button className="icon-btn" onClick={onToggleTheme} title="切换主题">
           {theme === 'dark' ? '🌙' : '🌞'}
@@ -10,8 +10,16 @@ export function GetProfile(arg1:string):Promise<main.HostProfile>;
 
 export function ImportProfiles(arg1:string,arg2:string):Promise<number>;
 
+export function ImportSSHConfig(arg1:string,arg2:Array<string>):Promise<number>;
+
+export function InspectCertificate(arg1:string):Promise<main.CertInfo>;
+
+export function InspectProfile(arg1:string):Promise<main.ProfileInspection>;
+
 export function ListProfiles():Promise<Array<main.HostProfile>>;
 
 export function Paths():Promise<Record<string, string>>;
 
+export function PreviewSSHConfig(arg1:string):Promise<Array<main.SSHConfigEntry>>;
+
 export function SaveProfile(arg1:main.HostProfile):Promise<string>;
@@ -18,6 +18,18 @@ export function ImportProfiles(arg1, arg2) {
   return window['go']['main']['ProfilesManager']['ImportProfiles'](arg1, arg2);
 }
 
+export function ImportSSHConfig(arg1, arg2) {
+  return window['go']['main']['ProfilesManager']['ImportSSHConfig'](arg1, arg2);
+}
+
+export function InspectCertificate(arg1) {
+  return window['go']['main']['ProfilesManager']['InspectCertificate'](arg1);
+}
+
+export function InspectProfile(arg1) {
+  return window['go']['main']['ProfilesManager']['InspectProfile'](arg1);
+}
+
 export function ListProfiles() {
   return window['go']['main']['ProfilesManager']['ListProfiles']();
 }
@@ -26,6 +38,10 @@ export function Paths() {
   return window['go']['main']['ProfilesManager']['Paths']();
 }
 
+export function PreviewSSHConfig(arg1) {
+  return window['go']['main']['ProfilesManager']['PreviewSSHConfig'](arg1);
+}
+
 export function SaveProfile(arg1) {
   return window['go']['main']['ProfilesManager']['SaveProfile'](arg1);
 }
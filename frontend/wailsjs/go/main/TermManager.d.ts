@@ -2,22 +2,62 @@
 // This file is automatically generated. DO NOT EDIT
 import {main} from '../models';
 
+export function AnswerKeyboardInteractive(arg1:string,arg2:Array<string>):Promise<void>;
+
+export function CancelCommand(arg1:string):Promise<void>;
+
+export function CancelPrompt(arg1:string):Promise<void>;
+
 export function Close(arg1:string):Promise<void>;
 
+export function ConfirmHostKey(arg1:string,arg2:boolean):Promise<void>;
+
+export function GetGlobalProxy():Promise<main.ProxyConfig>;
+
+export function GetScrollback(arg1:string,arg2:number):Promise<main.Scrollback>;
+
+export function ListForwards(arg1:string):Promise<Array<main.ForwardInfo>>;
+
+export function ListSerialPorts():Promise<Array<string>>;
+
 export function Resize(arg1:string,arg2:number,arg3:number):Promise<void>;
 
+export function RunCommand(arg1:string,arg2:string,arg3:string,arg4:number):Promise<main.CommandResult>;
+
 export function Send(arg1:string,arg2:string):Promise<void>;
 
+export function SetGlobalProxy(arg1:main.ProxyConfig):Promise<void>;
+
+export function SetOutputEncoding(arg1:string,arg2:string):Promise<void>;
+
+export function StartCommand(arg1:string,arg2:string,arg3:string,arg4:number):Promise<void>;
+
+export function StartDynamicForward(arg1:string,arg2:string,arg3:number,arg4:string,arg5:string):Promise<string>;
+
+export function StartLocal(arg1:main.LocalParams):Promise<string>;
+
 export function StartLocalForward(arg1:string,arg2:string,arg3:number,arg4:string,arg5:number):Promise<string>;
 
 export function StartRecording(arg1:string,arg2:string,arg3:boolean):Promise<string>;
 
+export function StartRemoteForward(arg1:string,arg2:string,arg3:number,arg4:string,arg5:number):Promise<string>;
+
 export function StartSSH(arg1:main.SSHParams):Promise<string>;
 
+export function StartSerial(arg1:main.SerialParams):Promise<string>;
+
+export function StartTelnet(arg1:main.TelnetParams):Promise<string>;
+
 export function StartWebProxy(arg1:string,arg2:number,arg3:string,arg4:number):Promise<string>;
 
 export function StartWebProxyViaSSH(arg1:string,arg2:number,arg3:string,arg4:number):Promise<string>;
 
+export function StopDynamicForward(arg1:string,arg2:string):Promise<void>;
+
 export function StopLocalForward(arg1:string,arg2:string):Promise<void>;
 
 export function StopRecording(arg1:string):Promise<void>;
+
+export function StopRemoteForward(arg1:string,arg2:string):Promise<void>;
+
+export function TestConnection(arg1:main.SSHParams):Promise<main.ConnectionReport>;
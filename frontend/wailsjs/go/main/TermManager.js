@@ -2,18 +2,74 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AnswerKeyboardInteractive(arg1, arg2) {
+  return window['go']['main']['TermManager']['AnswerKeyboardInteractive'](arg1, arg2);
+}
+
+export function CancelCommand(arg1) {
+  return window['go']['main']['TermManager']['CancelCommand'](arg1);
+}
+
+export function CancelPrompt(arg1) {
+  return window['go']['main']['TermManager']['CancelPrompt'](arg1);
+}
+
 export function Close(arg1) {
   return window['go']['main']['TermManager']['Close'](arg1);
 }
 
+export function ConfirmHostKey(arg1, arg2) {
+  return window['go']['main']['TermManager']['ConfirmHostKey'](arg1, arg2);
+}
+
+export function GetGlobalProxy() {
+  return window['go']['main']['TermManager']['GetGlobalProxy']();
+}
+
+export function GetScrollback(arg1, arg2) {
+  return window['go']['main']['TermManager']['GetScrollback'](arg1, arg2);
+}
+
+export function ListForwards(arg1) {
+  return window['go']['main']['TermManager']['ListForwards'](arg1);
+}
+
+export function ListSerialPorts() {
+  return window['go']['main']['TermManager']['ListSerialPorts']();
+}
+
 export function Resize(arg1, arg2, arg3) {
   return window['go']['main']['TermManager']['Resize'](arg1, arg2, arg3);
 }
 
+export function RunCommand(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['TermManager']['RunCommand'](arg1, arg2, arg3, arg4);
+}
+
 export function Send(arg1, arg2) {
   return window['go']['main']['TermManager']['Send'](arg1, arg2);
 }
 
+export function SetGlobalProxy(arg1) {
+  return window['go']['main']['TermManager']['SetGlobalProxy'](arg1);
+}
+
+export function SetOutputEncoding(arg1, arg2) {
+  return window['go']['main']['TermManager']['SetOutputEncoding'](arg1, arg2);
+}
+
+export function StartCommand(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['TermManager']['StartCommand'](arg1, arg2, arg3, arg4);
+}
+
+export function StartDynamicForward(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['TermManager']['StartDynamicForward'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function StartLocal(arg1) {
+  return window['go']['main']['TermManager']['StartLocal'](arg1);
+}
+
 export function StartLocalForward(arg1, arg2, arg3, arg4, arg5) {
   return window['go']['main']['TermManager']['StartLocalForward'](arg1, arg2, arg3, arg4, arg5);
 }
@@ -22,10 +78,22 @@ export function StartRecording(arg1, arg2, arg3) {
   return window['go']['main']['TermManager']['StartRecording'](arg1, arg2, arg3);
 }
 
+export function StartRemoteForward(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['TermManager']['StartRemoteForward'](arg1, arg2, arg3, arg4, arg5);
+}
+
 export function StartSSH(arg1) {
   return window['go']['main']['TermManager']['StartSSH'](arg1);
 }
 
+export function StartSerial(arg1) {
+  return window['go']['main']['TermManager']['StartSerial'](arg1);
+}
+
+export function StartTelnet(arg1) {
+  return window['go']['main']['TermManager']['StartTelnet'](arg1);
+}
+
 export function StartWebProxy(arg1, arg2, arg3, arg4) {
   return window['go']['main']['TermManager']['StartWebProxy'](arg1, arg2, arg3, arg4);
 }
@@ -34,6 +102,10 @@ export function StartWebProxyViaSSH(arg1, arg2, arg3, arg4) {
   return window['go']['main']['TermManager']['StartWebProxyViaSSH'](arg1, arg2, arg3, arg4);
 }
 
+export function StopDynamicForward(arg1, arg2) {
+  return window['go']['main']['TermManager']['StopDynamicForward'](arg1, arg2);
+}
+
 export function StopLocalForward(arg1, arg2) {
   return window['go']['main']['TermManager']['StopLocalForward'](arg1, arg2);
 }
@@ -41,3 +113,11 @@ export function StopLocalForward(arg1, arg2) {
 export function StopRecording(arg1) {
   return window['go']['main']['TermManager']['StopRecording'](arg1);
 }
+
+export function StopRemoteForward(arg1, arg2) {
+  return window['go']['main']['TermManager']['StopRemoteForward'](arg1, arg2);
+}
+
+export function TestConnection(arg1) {
+  return window['go']['main']['TermManager']['TestConnection'](arg1);
+}
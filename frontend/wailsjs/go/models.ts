@@ -5,6 +5,7 @@ export namespace main {
 	    password?: string;
 	    key_pem?: string;
 	    passphrase?: string;
+	    cert_pub?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new AuthInfo(source);
@@ -16,19 +17,325 @@ export namespace main {
 	        this.password = source["password"];
 	        this.key_pem = source["key_pem"];
 	        this.passphrase = source["passphrase"];
+	        this.cert_pub = source["cert_pub"];
 	    }
 	}
+	export class AuthInspection {
+	    type: string;
+	    keyType?: string;
+	    fingerprint?: string;
+	    encrypted?: boolean;
+	    cert?: CertInfo;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AuthInspection(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.type = source["type"];
+	        this.keyType = source["keyType"];
+	        this.fingerprint = source["fingerprint"];
+	        this.encrypted = source["encrypted"];
+	        this.cert = this.convertValues(source["cert"], CertInfo);
+	        this.error = source["error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CertInfo {
+	    keyId: string;
+	    serial: number;
+	    principals: string[];
+	    validAfter?: string;
+	    validBefore?: string;
+	    expired: boolean;
+	    notYetValid: boolean;
+	    ca: string;
+	    extensions?: string[];
+	    forceCommand?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CertInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.keyId = source["keyId"];
+	        this.serial = source["serial"];
+	        this.principals = source["principals"];
+	        this.validAfter = source["validAfter"];
+	        this.validBefore = source["validBefore"];
+	        this.expired = source["expired"];
+	        this.notYetValid = source["notYetValid"];
+	        this.ca = source["ca"];
+	        this.extensions = source["extensions"];
+	        this.forceCommand = source["forceCommand"];
+	    }
+	}
+	export class CommandResult {
+	    id: string;
+	    exitCode: number;
+	    signal?: string;
+	    stdout: string;
+	    stderr: string;
+	    truncated?: boolean;
+	    timedOut?: boolean;
+	    canceled?: boolean;
+	    durationMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CommandResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.exitCode = source["exitCode"];
+	        this.signal = source["signal"];
+	        this.stdout = source["stdout"];
+	        this.stderr = source["stderr"];
+	        this.truncated = source["truncated"];
+	        this.timedOut = source["timedOut"];
+	        this.canceled = source["canceled"];
+	        this.durationMs = source["durationMs"];
+	    }
+	}
+	export class ConnectionReport {
+	    ok: boolean;
+	    error?: string;
+	    proxy?: string;
+	    totalMs: number;
+	    hops: HopDiagnostics[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ConnectionReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ok = source["ok"];
+	        this.error = source["error"];
+	        this.proxy = source["proxy"];
+	        this.totalMs = source["totalMs"];
+	        this.hops = this.convertValues(source["hops"], HopDiagnostics);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DiagAlgorithms {
+	    kex: string;
+	    hostKey: string;
+	    cipherOut: string;
+	    cipherIn: string;
+	    macOut?: string;
+	    macIn?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DiagAlgorithms(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kex = source["kex"];
+	        this.hostKey = source["hostKey"];
+	        this.cipherOut = source["cipherOut"];
+	        this.cipherIn = source["cipherIn"];
+	        this.macOut = source["macOut"];
+	        this.macIn = source["macIn"];
+	    }
+	}
+	export class DiagStage {
+	    name: string;
+	    ok: boolean;
+	    durationMs: number;
+	    detail?: string;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DiagStage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.ok = source["ok"];
+	        this.durationMs = source["durationMs"];
+	        this.detail = source["detail"];
+	        this.error = source["error"];
+	    }
+	}
+	export class ForwardInfo {
+	    id: string;
+	    type: string;
+	    bind: string;
+	    target?: string;
+	    listening: boolean;
+	    active: number;
+	    connections: number;
+	    bytesOut: number;
+	    bytesIn: number;
+	    lastError?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ForwardInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.type = source["type"];
+	        this.bind = source["bind"];
+	        this.target = source["target"];
+	        this.listening = source["listening"];
+	        this.active = source["active"];
+	        this.connections = source["connections"];
+	        this.bytesOut = source["bytesOut"];
+	        this.bytesIn = source["bytesIn"];
+	        this.lastError = source["lastError"];
+	    }
+	}
+	export class ForwardSpec {
+	    type: string;
+	    bindHost?: string;
+	    bindPort: number;
+	    targetHost?: string;
+	    targetPort?: number;
+	    username?: string;
+	    password?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ForwardSpec(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.type = source["type"];
+	        this.bindHost = source["bindHost"];
+	        this.bindPort = source["bindPort"];
+	        this.targetHost = source["targetHost"];
+	        this.targetPort = source["targetPort"];
+	        this.username = source["username"];
+	        this.password = source["password"];
+	    }
+	}
+	export class HopDiagnostics {
+	    label: string;
+	    address: string;
+	    user: string;
+	    stages: DiagStage[];
+	    serverVersion?: string;
+	    banner?: string;
+	    hostKey?: string;
+	    hostKeyStatus?: string;
+	    algorithms?: DiagAlgorithms;
+	    authOffered?: string[];
+	    authAttempted?: string[];
+	    authSucceeded?: string;
+	    ok: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new HopDiagnostics(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.label = source["label"];
+	        this.address = source["address"];
+	        this.user = source["user"];
+	        this.stages = this.convertValues(source["stages"], DiagStage);
+	        this.serverVersion = source["serverVersion"];
+	        this.banner = source["banner"];
+	        this.hostKey = source["hostKey"];
+	        this.hostKeyStatus = source["hostKeyStatus"];
+	        this.algorithms = this.convertValues(source["algorithms"], DiagAlgorithms);
+	        this.authOffered = source["authOffered"];
+	        this.authAttempted = source["authAttempted"];
+	        this.authSucceeded = source["authSucceeded"];
+	        this.ok = source["ok"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class HostProfile {
 	    id: string;
 	    name: string;
+	    kind?: string;
+	    serial?: SerialParams;
+	    telnet?: TelnetParams;
 	    host: string;
 	    port: number;
 	    username: string;
 	    auth: AuthInfo;
 	    keepAliveSec?: number;
 	    timeoutSec?: number;
+	    autoReconnect?: boolean;
+	    reconnectMaxTries?: number;
+	    forwardAgent?: string;
+	    env?: Record<string, string>;
+	    startupCommands?: string[];
+	    promptPattern?: string;
+	    algorithmPreset?: string;
+	    keyExchanges?: string[];
+	    ciphers?: string[];
+	    macs?: string[];
+	    hostKeyAlgorithms?: string[];
+	    outputEncoding?: string;
+	    scrollbackBytes?: number;
 	    cols?: number;
 	    rows?: number;
+	    jumps?: JumpHost[];
+	    proxy?: ProxyConfig;
+	    forwards?: ForwardSpec[];
 	    gatewayHost?: string;
 	    gatewayPort?: number;
 	    gatewayUser?: string;
@@ -48,14 +355,33 @@ export namespace main {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.id = source["id"];
 	        this.name = source["name"];
+	        this.kind = source["kind"];
+	        this.serial = this.convertValues(source["serial"], SerialParams);
+	        this.telnet = this.convertValues(source["telnet"], TelnetParams);
 	        this.host = source["host"];
 	        this.port = source["port"];
 	        this.username = source["username"];
 	        this.auth = this.convertValues(source["auth"], AuthInfo);
 	        this.keepAliveSec = source["keepAliveSec"];
 	        this.timeoutSec = source["timeoutSec"];
+	        this.autoReconnect = source["autoReconnect"];
+	        this.reconnectMaxTries = source["reconnectMaxTries"];
+	        this.forwardAgent = source["forwardAgent"];
+	        this.env = source["env"];
+	        this.startupCommands = source["startupCommands"];
+	        this.promptPattern = source["promptPattern"];
+	        this.algorithmPreset = source["algorithmPreset"];
+	        this.keyExchanges = source["keyExchanges"];
+	        this.ciphers = source["ciphers"];
+	        this.macs = source["macs"];
+	        this.hostKeyAlgorithms = source["hostKeyAlgorithms"];
+	        this.outputEncoding = source["outputEncoding"];
+	        this.scrollbackBytes = source["scrollbackBytes"];
 	        this.cols = source["cols"];
 	        this.rows = source["rows"];
+	        this.jumps = this.convertValues(source["jumps"], JumpHost);
+	        this.proxy = this.convertValues(source["proxy"], ProxyConfig);
+	        this.forwards = this.convertValues(source["forwards"], ForwardSpec);
 	        this.gatewayHost = source["gatewayHost"];
 	        this.gatewayPort = source["gatewayPort"];
 	        this.gatewayUser = source["gatewayUser"];
@@ -86,6 +412,120 @@ export namespace main {
 		    return a;
 		}
 	}
+	export class JumpHost {
+	    host: string;
+	    port?: number;
+	    user: string;
+	    auth: AuthInfo;
+	
+	    static createFrom(source: any = {}) {
+	        return new JumpHost(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.host = source["host"];
+	        this.port = source["port"];
+	        this.user = source["user"];
+	        this.auth = this.convertValues(source["auth"], AuthInfo);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class LocalParams {
+	    Shell: string;
+	    Dir: string;
+	    Env: Record<string, string>;
+	    Cols: number;
+	    Rows: number;
+	    ScrollbackBytes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new LocalParams(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Shell = source["Shell"];
+	        this.Dir = source["Dir"];
+	        this.Env = source["Env"];
+	        this.Cols = source["Cols"];
+	        this.Rows = source["Rows"];
+	        this.ScrollbackBytes = source["ScrollbackBytes"];
+	    }
+	}
+	export class ProfileInspection {
+	    id: string;
+	    name: string;
+	    auth: AuthInspection;
+	    jumps?: AuthInspection[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ProfileInspection(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.auth = this.convertValues(source["auth"], AuthInspection);
+	        this.jumps = this.convertValues(source["jumps"], AuthInspection);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ProxyConfig {
+	    type: string;
+	    host?: string;
+	    port?: number;
+	    username?: string;
+	    password?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProxyConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.type = source["type"];
+	        this.host = source["host"];
+	        this.port = source["port"];
+	        this.username = source["username"];
+	        this.password = source["password"];
+	    }
+	}
 	export class RemoteFile {
 	    name: string;
 	    path: string;
@@ -108,6 +548,42 @@ export namespace main {
 	        this.isDir = source["isDir"];
 	    }
 	}
+	export class SSHConfigEntry {
+	    alias: string;
+	    profile: HostProfile;
+	    exists: boolean;
+	    warnings?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SSHConfigEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.alias = source["alias"];
+	        this.profile = this.convertValues(source["profile"], HostProfile);
+	        this.exists = source["exists"];
+	        this.warnings = source["warnings"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class SSHParams {
 	    Host: string;
 	    Port: number;
@@ -116,17 +592,27 @@ export namespace main {
 	    AuthType: string;
 	    KeyPEM: string;
 	    Passphrase: string;
+	    CertPub: string;
 	    Cols: number;
 	    Rows: number;
 	    KeepAliveSec: number;
 	    TimeoutSec: number;
-	    GatewayHost: string;
-	    GatewayPort: number;
-	    GatewayUser: string;
-	    GatewayAuth: string;
-	    GatewayPassword: string;
-	    GatewayKeyPEM: string;
-	    GatewayPassphrase: string;
+	    OutputEncoding: string;
+	    ScrollbackBytes: number;
+	    AlgorithmPreset: string;
+	    KeyExchanges: string[];
+	    Ciphers: string[];
+	    MACs: string[];
+	    HostKeyAlgorithms: string[];
+	    ForwardAgent: string;
+	    AutoReconnect: boolean;
+	    ReconnectMaxTries: number;
+	    Env: Record<string, string>;
+	    StartupCommands: string[];
+	    PromptPattern: string;
+	    Jumps: JumpHost[];
+	    Proxy: ProxyConfig;
+	    Forwards: ForwardSpec[];
 	
 	    static createFrom(source: any = {}) {
 	        return new SSHParams(source);
@@ -141,17 +627,107 @@ export namespace main {
 	        this.AuthType = source["AuthType"];
 	        this.KeyPEM = source["KeyPEM"];
 	        this.Passphrase = source["Passphrase"];
+	        this.CertPub = source["CertPub"];
 	        this.Cols = source["Cols"];
 	        this.Rows = source["Rows"];
 	        this.KeepAliveSec = source["KeepAliveSec"];
 	        this.TimeoutSec = source["TimeoutSec"];
-	        this.GatewayHost = source["GatewayHost"];
-	        this.GatewayPort = source["GatewayPort"];
-	        this.GatewayUser = source["GatewayUser"];
-	        this.GatewayAuth = source["GatewayAuth"];
-	        this.GatewayPassword = source["GatewayPassword"];
-	        this.GatewayKeyPEM = source["GatewayKeyPEM"];
-	        this.GatewayPassphrase = source["GatewayPassphrase"];
+	        this.OutputEncoding = source["OutputEncoding"];
+	        this.ScrollbackBytes = source["ScrollbackBytes"];
+	        this.AlgorithmPreset = source["AlgorithmPreset"];
+	        this.KeyExchanges = source["KeyExchanges"];
+	        this.Ciphers = source["Ciphers"];
+	        this.MACs = source["MACs"];
+	        this.HostKeyAlgorithms = source["HostKeyAlgorithms"];
+	        this.ForwardAgent = source["ForwardAgent"];
+	        this.AutoReconnect = source["AutoReconnect"];
+	        this.ReconnectMaxTries = source["ReconnectMaxTries"];
+	        this.Env = source["Env"];
+	        this.StartupCommands = source["StartupCommands"];
+	        this.PromptPattern = source["PromptPattern"];
+	        this.Jumps = this.convertValues(source["Jumps"], JumpHost);
+	        this.Proxy = this.convertValues(source["Proxy"], ProxyConfig);
+	        this.Forwards = this.convertValues(source["Forwards"], ForwardSpec);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Scrollback {
+	    data: string;
+	    from: number;
+	    next: number;
+	    truncated: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Scrollback(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.data = source["data"];
+	        this.from = source["from"];
+	        this.next = source["next"];
+	        this.truncated = source["truncated"];
+	    }
+	}
+	export class SerialParams {
+	    device: string;
+	    baudRate: number;
+	    dataBits?: number;
+	    parity?: string;
+	    stopBits?: string;
+	    flowControl?: string;
+	    scrollbackBytes?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SerialParams(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.device = source["device"];
+	        this.baudRate = source["baudRate"];
+	        this.dataBits = source["dataBits"];
+	        this.parity = source["parity"];
+	        this.stopBits = source["stopBits"];
+	        this.flowControl = source["flowControl"];
+	        this.scrollbackBytes = source["scrollbackBytes"];
+	    }
+	}
+	export class TelnetParams {
+	    host: string;
+	    port: number;
+	    timeoutSec?: number;
+	    terminalType?: string;
+	    scrollbackBytes?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TelnetParams(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.host = source["host"];
+	        this.port = source["port"];
+	        this.timeoutSec = source["timeoutSec"];
+	        this.terminalType = source["terminalType"];
+	        this.scrollbackBytes = source["scrollbackBytes"];
 	    }
 	}
 	export class TransferProgress {
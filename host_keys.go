@@ -0,0 +1,170 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"xgoterm/internal/store"
+)
+
+// HostKeyPrompt is sent to the frontend on first contact with a host
+type HostKeyPrompt struct {
+	ID          string `json:"id"`
+	Host        string `json:"host"`
+	KeyType     string `json:"keyType"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// knownHostsMu serializes reads and appends of the known_hosts file
+var knownHostsMu sync.Mutex
+
+// hostKeyCallback verifies server keys against store.KnownHostsPath(), asking the
+// frontend to trust unknown hosts and refusing keys that differ from the recorded one.
+func (tm *TermManager) hostKeyCallback() ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := checkKnownHost(hostname, remote, key)
+		if err == nil {
+			return nil
+		}
+		var ke *knownhosts.KeyError
+		if !errors.As(err, &ke) {
+			return err
+		}
+		if len(ke.Want) > 0 {
+			// withKnownHostKeyAlgorithms asks for the recorded types first, so a key
+			// of another type means the server no longer has the recorded one
+			old := ke.Want[0]
+			if i := slices.IndexFunc(ke.Want, func(k knownhosts.KnownKey) bool { return k.Key.Type() == key.Type() }); i >= 0 {
+				old = ke.Want[i]
+			}
+			return fmt.Errorf("host key for %s has changed (possible MITM attack): known %s %s (%s:%d), presented %s %s; remove the old entry from %s if the change is expected",
+				hostname, old.Key.Type(), ssh.FingerprintSHA256(old.Key), old.Filename, old.Line,
+				key.Type(), ssh.FingerprintSHA256(key), store.KnownHostsPath())
+		}
+
+		prompt := HostKeyPrompt{
+			ID:          newPromptID(),
+			Host:        hostname,
+			KeyType:     key.Type(),
+			Fingerprint: ssh.FingerprintSHA256(key),
+		}
+		log.Printf("[HostKey] unknown host %s (%s %s), asking user", hostname, prompt.KeyType, prompt.Fingerprint)
+		r, err := tm.askFrontend(prompt.ID, "ssh:hostkey", prompt)
+		if err != nil {
+			return fmt.Errorf("host key for %s not confirmed: %w", hostname, err)
+		}
+		if !r.accept {
+			return fmt.Errorf("host key for %s rejected: %w", hostname, errPromptCancelled)
+		}
+		return addKnownHost(hostname, key)
+	}
+}
+
+// ConfirmHostKey answers a "ssh:hostkey" prompt
+func (tm *TermManager) ConfirmHostKey(promptID string, accept bool) error {
+	return tm.answerPrompt(promptID, promptReply{accept: accept})
+}
+
+// withKnownHostKeyAlgorithms returns cfg with the host key algorithms whose key types
+// known_hosts already records for addr moved to the front, as OpenSSH does, so a
+// server with keys of several types is verified against the recorded one while a
+// server that dropped it still negotiates and is reported as a changed key.
+func withKnownHostKeyAlgorithms(cfg *ssh.ClientConfig, addr string) *ssh.ClientConfig {
+	known := knownKeyTypes(addr)
+	if len(known) == 0 {
+		return cfg
+	}
+	algos := cfg.HostKeyAlgorithms
+	if len(algos) == 0 {
+		algos = ssh.SupportedAlgorithms().HostKeys
+	}
+	c := *cfg
+	c.HostKeyAlgorithms = preferKeyTypes(algos, known)
+	return &c
+}
+
+// preferKeyTypes stably moves the algorithms producing one of types to the front
+func preferKeyTypes(algos, types []string) []string {
+	var first, rest []string
+	for _, a := range algos {
+		t := a
+		if a == ssh.KeyAlgoRSASHA256 || a == ssh.KeyAlgoRSASHA512 {
+			t = ssh.KeyAlgoRSA
+		}
+		if slices.Contains(types, t) {
+			first = append(first, a)
+		} else {
+			rest = append(rest, a)
+		}
+	}
+	return append(first, rest...)
+}
+
+// knownKeyTypes lists the key types known_hosts records for addr
+func knownKeyTypes(addr string) []string {
+	// checking a key no host has makes knownhosts report every recorded one
+	probe, err := ssh.NewPublicKey(ed25519.PublicKey(make([]byte, ed25519.PublicKeySize)))
+	if err != nil {
+		return nil
+	}
+	var ke *knownhosts.KeyError
+	if !errors.As(checkKnownHost(addr, remoteAddr(addr), probe), &ke) {
+		return nil
+	}
+	var types []string
+	for _, k := range ke.Want {
+		if !slices.Contains(types, k.Key.Type()) {
+			types = append(types, k.Key.Type())
+		}
+	}
+	return types
+}
+
+// remoteAddr approximates the net.Addr the host key callback sees for addr
+func remoteAddr(addr string) net.Addr {
+	host, port, _ := net.SplitHostPort(addr)
+	remote := &net.TCPAddr{IP: net.ParseIP(host)}
+	if remote.IP == nil {
+		remote.IP = net.IPv4zero
+	}
+	remote.Port, _ = strconv.Atoi(port)
+	return remote
+}
+
+func checkKnownHost(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	knownHostsMu.Lock()
+	defer knownHostsMu.Unlock()
+	path := store.KnownHostsPath()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	_ = f.Close()
+	cb, err := knownhosts.New(path)
+	if err != nil {
+		return err
+	}
+	return cb(hostname, remote, key)
+}
+
+func addKnownHost(hostname string, key ssh.PublicKey) error {
+	knownHostsMu.Lock()
+	defer knownHostsMu.Unlock()
+	f, err := os.OpenFile(store.KnownHostsPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key) + "\n")
+	return err
+}
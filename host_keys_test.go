@@ -0,0 +1,128 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"slices"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"xgoterm/internal/store"
+)
+
+// useTempKnownHosts points store paths at an empty home directory
+func useTempKnownHosts(t *testing.T) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("HOME", home)
+	if err := store.EnsureDirs(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newEd25519Key(t *testing.T) ssh.PublicKey {
+	t.Helper()
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func newECDSAKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := ssh.NewPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestCheckKnownHost(t *testing.T) {
+	useTempKnownHosts(t)
+	key, other := newEd25519Key(t), newEd25519Key(t)
+	const addr = "web.example.com:2222"
+	if err := addKnownHost(addr, key); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkKnownHost(addr, remoteAddr(addr), key); err != nil {
+		t.Errorf("recorded key rejected: %v", err)
+	}
+	var ke *knownhosts.KeyError
+	if err := checkKnownHost(addr, remoteAddr(addr), other); !errors.As(err, &ke) || len(ke.Want) != 1 {
+		t.Errorf("changed key: err = %v, want a KeyError naming the recorded key", err)
+	}
+	ke = nil
+	if err := checkKnownHost("web.example.com:22", remoteAddr("web.example.com:22"), key); !errors.As(err, &ke) || len(ke.Want) != 0 {
+		t.Errorf("other port: err = %v, want an unknown-host KeyError", err)
+	}
+}
+
+func TestKnownKeyTypes(t *testing.T) {
+	useTempKnownHosts(t)
+	const addr = "db.example.com:22"
+	if got := knownKeyTypes(addr); len(got) != 0 {
+		t.Errorf("unknown host: knownKeyTypes = %v, want none", got)
+	}
+	for _, k := range []ssh.PublicKey{newECDSAKey(t), newEd25519Key(t), newEd25519Key(t)} {
+		if err := addKnownHost(addr, k); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := knownKeyTypes(addr)
+	slices.Sort(got)
+	if want := []string{ssh.KeyAlgoECDSA256, ssh.KeyAlgoED25519}; !slices.Equal(got, want) {
+		t.Errorf("knownKeyTypes = %v, want %v", got, want)
+	}
+}
+
+func TestPreferKeyTypes(t *testing.T) {
+	algos := []string{ssh.KeyAlgoED25519, ssh.KeyAlgoECDSA256, ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA}
+	tests := []struct {
+		types []string
+		want  []string
+	}{
+		{[]string{ssh.KeyAlgoRSA}, []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA, ssh.KeyAlgoED25519, ssh.KeyAlgoECDSA256}},
+		{[]string{ssh.KeyAlgoECDSA256}, []string{ssh.KeyAlgoECDSA256, ssh.KeyAlgoED25519, ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA}},
+		{[]string{ssh.KeyAlgoED25519}, algos},
+		{[]string{ssh.KeyAlgoDSA}, algos},
+	}
+	for _, tt := range tests {
+		if got := preferKeyTypes(algos, tt.types); !slices.Equal(got, tt.want) {
+			t.Errorf("preferKeyTypes(%v) = %v, want %v", tt.types, got, tt.want)
+		}
+	}
+}
+
+func TestWithKnownHostKeyAlgorithms(t *testing.T) {
+	useTempKnownHosts(t)
+	cfg := &ssh.ClientConfig{}
+	if got := withKnownHostKeyAlgorithms(cfg, "new.example.com:22"); got != cfg {
+		t.Error("config of an unknown host was changed")
+	}
+	if err := addKnownHost("old.example.com:22", newECDSAKey(t)); err != nil {
+		t.Fatal(err)
+	}
+	got := withKnownHostKeyAlgorithms(cfg, "old.example.com:22").HostKeyAlgorithms
+	if len(got) != len(ssh.SupportedAlgorithms().HostKeys) || got[0] != ssh.KeyAlgoECDSA256 {
+		t.Errorf("HostKeyAlgorithms = %v, want every supported algorithm with %s first", got, ssh.KeyAlgoECDSA256)
+	}
+	if len(cfg.HostKeyAlgorithms) != 0 {
+		t.Error("the caller's config was modified")
+	}
+}
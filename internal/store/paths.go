@@ -32,5 +32,7 @@ func EnsureDirs() error {
 	return nil
 }
 
-func MasterKeyPath() string { return filepath.Join(StorageDir(), "master.key.enc") }
-func HostsPath() string     { return filepath.Join(StorageDir(), "hosts.enc.json") }
+func MasterKeyPath() string  { return filepath.Join(StorageDir(), "master.key.enc") }
+func HostsPath() string      { return filepath.Join(StorageDir(), "hosts.enc.json") }
+func KnownHostsPath() string { return filepath.Join(StorageDir(), "known_hosts") }
+func ProxyPath() string      { return filepath.Join(StorageDir(), "proxy.enc.json") }
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// KeyboardInteractivePrompt is sent to the frontend for each keyboard-interactive challenge
+type KeyboardInteractivePrompt struct {
+	ID          string                        `json:"id"`
+	Host        string                        `json:"host"`
+	User        string                        `json:"user"`
+	Name        string                        `json:"name"`
+	Instruction string                        `json:"instruction"`
+	Questions   []KeyboardInteractiveQuestion `json:"questions"`
+}
+
+type KeyboardInteractiveQuestion struct {
+	Prompt string `json:"prompt"`
+	Echo   bool   `json:"echo"`
+}
+
+// keyboardInteractive forwards server challenges (OTP, 2FA, ...) to the frontend and
+// blocks the handshake until the user answers or cancels. trace is told when the
+// server starts a challenge.
+func (tm *TermManager) keyboardInteractive(host, user string, trace func(method string)) ssh.AuthMethod {
+	return ssh.KeyboardInteractive(func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+		trace("keyboard-interactive")
+		if len(questions) == 0 {
+			if instruction != "" {
+				log.Printf("[KeyboardInteractive] %s@%s: %s", user, host, instruction)
+			}
+			return []string{}, nil
+		}
+		prompt := kbdPrompt(host, user, name, instruction, questions, echos)
+		r, err := tm.askFrontend(prompt.ID, "ssh:kbd-interactive", prompt)
+		if err != nil {
+			return nil, fmt.Errorf("keyboard-interactive for %s@%s: %w", user, host, err)
+		}
+		return kbdAnswers(r, len(questions))
+	})
+}
+
+// kbdPrompt builds the frontend prompt for one challenge; echos may be shorter than questions
+func kbdPrompt(host, user, name, instruction string, questions []string, echos []bool) KeyboardInteractivePrompt {
+	prompt := KeyboardInteractivePrompt{
+		ID:          newPromptID(),
+		Host:        host,
+		User:        user,
+		Name:        name,
+		Instruction: instruction,
+	}
+	for i, q := range questions {
+		prompt.Questions = append(prompt.Questions, KeyboardInteractiveQuestion{Prompt: q, Echo: i < len(echos) && echos[i]})
+	}
+	return prompt
+}
+
+// kbdAnswers checks the frontend's reply to a challenge of n questions
+func kbdAnswers(r promptReply, n int) ([]string, error) {
+	if !r.accept {
+		return nil, errPromptCancelled
+	}
+	if len(r.answers) != n {
+		return nil, fmt.Errorf("keyboard-interactive: expected %d answers, got %d", n, len(r.answers))
+	}
+	return r.answers, nil
+}
+
+// AnswerKeyboardInteractive answers a "ssh:kbd-interactive" prompt, one answer per question
+func (tm *TermManager) AnswerKeyboardInteractive(promptID string, answers []string) error {
+	return tm.answerPrompt(promptID, promptReply{accept: true, answers: answers})
+}
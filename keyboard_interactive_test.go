@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestKbdPrompt(t *testing.T) {
+	p := kbdPrompt("h", "u", "2FA", "enter codes", []string{"Password: ", "OTP: ", "PIN: "}, []bool{false, true})
+	want := []KeyboardInteractiveQuestion{{"Password: ", false}, {"OTP: ", true}, {"PIN: ", false}}
+	if p.ID == "" || p.Host != "h" || p.User != "u" || p.Name != "2FA" || p.Instruction != "enter codes" || !slices.Equal(p.Questions, want) {
+		t.Errorf("kbdPrompt = %+v", p)
+	}
+}
+
+func TestKbdAnswers(t *testing.T) {
+	if got, err := kbdAnswers(promptReply{accept: true, answers: []string{"pw", "123456"}}, 2); err != nil || !slices.Equal(got, []string{"pw", "123456"}) {
+		t.Errorf("kbdAnswers = %v, %v", got, err)
+	}
+	if _, err := kbdAnswers(promptReply{accept: false}, 1); !errors.Is(err, errPromptCancelled) {
+		t.Errorf("cancelled: err = %v, want errPromptCancelled", err)
+	}
+	if _, err := kbdAnswers(promptReply{accept: true, answers: []string{"pw"}}, 2); err == nil {
+		t.Error("too few answers accepted")
+	}
+}
+
+func TestAnswerPrompt(t *testing.T) {
+	tm := NewTermManager()
+	if err := tm.AnswerKeyboardInteractive("missing", nil); err == nil {
+		t.Error("answer to an unknown prompt accepted")
+	}
+	ch := make(chan promptReply, 1)
+	tm.prompts["p1"] = ch
+	if err := tm.AnswerKeyboardInteractive("p1", []string{"123456"}); err != nil {
+		t.Fatal(err)
+	}
+	// a second answer must not block the caller
+	if err := tm.CancelPrompt("p1"); err != nil {
+		t.Fatal(err)
+	}
+	if r := <-ch; !r.accept || !slices.Equal(r.answers, []string{"123456"}) {
+		t.Errorf("reply = %+v", r)
+	}
+}
+
+// Challenges without questions only carry a message and must not reach the frontend
+func TestKeyboardInteractiveInfoOnly(t *testing.T) {
+	tm := NewTermManager()
+	var traced []string
+	scfg := &ssh.ServerConfig{KeyboardInteractiveCallback: func(_ ssh.ConnMetadata, c ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error) {
+		if _, err := c("", "Authorized use only", nil, nil); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}}
+	err := handshake(t, []ssh.AuthMethod{tm.keyboardInteractive("h", "u", func(m string) { traced = append(traced, m) })}, scfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(traced, []string{"keyboard-interactive"}) {
+		t.Errorf("traced %v", traced)
+	}
+}
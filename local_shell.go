@@ -0,0 +1,242 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf16"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// LocalParams describes a local shell session
+type LocalParams struct {
+	Shell string // command line; empty picks pwsh, powershell or %ComSpec%
+	Dir   string // working directory, default the user's home
+	Env   map[string]string
+	Cols  int
+	Rows  int
+	// output kept for GetScrollback, 0 = 1 MiB
+	ScrollbackBytes int
+}
+
+// StartLocal spawns the user's shell in a local pseudo console (ConPTY). The
+// session is driven through Send/Resize/Close like an SSH session.
+func (tm *TermManager) StartLocal(p LocalParams) (string, error) {
+	shell := p.Shell
+	if shell == "" {
+		shell = defaultShell()
+	}
+	dir := p.Dir
+	if dir == "" {
+		dir = homeDir()
+	}
+	title := shell
+	if i := strings.LastIndexAny(title, `\/`); i >= 0 {
+		title = title[i+1:]
+	}
+	return tm.startBackend(&localShell{cmdLine: shell, dir: dir, env: p.Env}, "local:"+title, p.Cols, p.Rows, p.ScrollbackBytes)
+}
+
+// defaultShell prefers PowerShell 7, then Windows PowerShell, then cmd.exe
+func defaultShell() string {
+	for _, name := range []string{"pwsh.exe", "powershell.exe"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path
+		}
+	}
+	if cs := os.Getenv("ComSpec"); cs != "" {
+		return cs
+	}
+	return "cmd.exe"
+}
+
+// localShell runs a process attached to a Windows pseudo console
+type localShell struct {
+	cmdLine string
+	dir     string
+	env     map[string]string
+
+	console windows.Handle
+	process windows.Handle
+	in      *os.File // writes go to the console input
+	out     *os.File // console output
+
+	exited    chan struct{}
+	exitCode  int
+	closeOnce sync.Once
+}
+
+func (l *localShell) Start(cols, rows int) error {
+	var inR, inW, outR, outW windows.Handle
+	if err := windows.CreatePipe(&inR, &inW, nil, 0); err != nil {
+		return fmt.Errorf("create pipe: %w", err)
+	}
+	if err := windows.CreatePipe(&outR, &outW, nil, 0); err != nil {
+		windows.CloseHandle(inR)
+		windows.CloseHandle(inW)
+		return fmt.Errorf("create pipe: %w", err)
+	}
+	err := windows.CreatePseudoConsole(consoleSize(cols, rows), inR, outW, 0, &l.console)
+	// the console duplicated its ends of the pipes
+	windows.CloseHandle(inR)
+	windows.CloseHandle(outW)
+	if err != nil {
+		windows.CloseHandle(inW)
+		windows.CloseHandle(outR)
+		return fmt.Errorf("create pseudo console (requires Windows 10 1809 or later): %w", err)
+	}
+	l.in = os.NewFile(uintptr(inW), "conpty-in")
+	l.out = os.NewFile(uintptr(outR), "conpty-out")
+
+	if err := l.spawn(); err != nil {
+		windows.ClosePseudoConsole(l.console)
+		_ = l.in.Close()
+		_ = l.out.Close()
+		return err
+	}
+
+	l.exited = make(chan struct{})
+	go func() {
+		_, _ = windows.WaitForSingleObject(l.process, windows.INFINITE)
+		var code uint32
+		if windows.GetExitCodeProcess(l.process, &code) == nil {
+			l.exitCode = int(code)
+		} else {
+			l.exitCode = -1
+		}
+		windows.CloseHandle(l.process)
+		close(l.exited)
+		// the console keeps the output pipe open until it is closed, so reads would never see EOF
+		l.closeConsole()
+	}()
+	return nil
+}
+
+// spawn starts cmdLine attached to l.console
+func (l *localShell) spawn() error {
+	attrs, err := windows.NewProcThreadAttributeList(1)
+	if err != nil {
+		return err
+	}
+	defer attrs.Delete()
+	// the attribute value is the HPCON itself, not a pointer to it
+	if err := attrs.Update(windows.PROC_THREAD_ATTRIBUTE_PSEUDOCONSOLE, *(*unsafe.Pointer)(unsafe.Pointer(&l.console)), unsafe.Sizeof(l.console)); err != nil {
+		return err
+	}
+	si := &windows.StartupInfoEx{ProcThreadAttributeList: attrs.List()}
+	si.Cb = uint32(unsafe.Sizeof(*si))
+	si.Flags = windows.STARTF_USESTDHANDLES // keep the child off our own std handles
+
+	cmdLine, err := windows.UTF16PtrFromString(l.cmdLine)
+	if err != nil {
+		return err
+	}
+	var dir *uint16
+	if l.dir != "" {
+		if dir, err = windows.UTF16PtrFromString(l.dir); err != nil {
+			return err
+		}
+	}
+	var env *uint16
+	if len(l.env) > 0 {
+		env = envBlock(l.env)
+	}
+	var pi windows.ProcessInformation
+	flags := uint32(windows.EXTENDED_STARTUPINFO_PRESENT | windows.CREATE_UNICODE_ENVIRONMENT)
+	if err := windows.CreateProcess(nil, cmdLine, nil, nil, false, flags, env, dir, &si.StartupInfo, &pi); err != nil {
+		return fmt.Errorf("start %s: %w", l.cmdLine, err)
+	}
+	windows.CloseHandle(pi.Thread)
+	l.process = pi.Process
+	return nil
+}
+
+// envBlock merges overrides into the current environment as a CreateProcess block
+func envBlock(overrides map[string]string) *uint16 {
+	var b []uint16
+	for _, kv := range mergeEnv(os.Environ(), overrides) {
+		b = append(b, utf16.Encode([]rune(kv))...)
+		b = append(b, 0)
+	}
+	b = append(b, 0)
+	return &b[0]
+}
+
+// mergeEnv applies overrides to base ("k=v" entries). Names are case-insensitive
+// on Windows, so an override replaces Path as well as PATH, and the result is
+// sorted by upper-cased name as CreateProcess expects.
+func mergeEnv(base []string, overrides map[string]string) []string {
+	vars := make(map[string]string) // upper-cased name -> "k=v"
+	for _, kv := range base {
+		if k, _, ok := strings.Cut(kv, "="); ok && k != "" {
+			vars[strings.ToUpper(k)] = kv
+		}
+	}
+	for k, v := range overrides {
+		vars[strings.ToUpper(k)] = k + "=" + v
+	}
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	env := make([]string, len(keys))
+	for i, k := range keys {
+		env[i] = vars[k]
+	}
+	return env
+}
+
+func consoleSize(cols, rows int) windows.Coord {
+	return windows.Coord{X: int16(max(cols, 1)), Y: int16(max(rows, 1))}
+}
+
+func (l *localShell) Read(p []byte) (int, error) {
+	if l.out == nil {
+		return 0, errors.New("local shell not started")
+	}
+	return l.out.Read(p)
+}
+
+func (l *localShell) Write(p []byte) (int, error) {
+	if l.in == nil {
+		return 0, errors.New("local shell not started")
+	}
+	return l.in.Write(p)
+}
+
+func (l *localShell) Resize(cols, rows int) error {
+	return windows.ResizePseudoConsole(l.console, consoleSize(cols, rows))
+}
+
+func (l *localShell) Wait() (int, error) {
+	if l.exited == nil {
+		return -1, nil
+	}
+	<-l.exited
+	return l.exitCode, nil
+}
+
+// closeConsole ends the console, which terminates attached processes and
+// makes the output pipe report EOF
+func (l *localShell) closeConsole() {
+	l.closeOnce.Do(func() {
+		windows.ClosePseudoConsole(l.console)
+	})
+}
+
+func (l *localShell) Close() error {
+	if l.exited == nil {
+		return nil
+	}
+	l.closeConsole()
+	<-l.exited
+	_ = l.in.Close()
+	return l.out.Close()
+}
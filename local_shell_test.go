@@ -0,0 +1,24 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMergeEnv(t *testing.T) {
+	base := []string{"Path=C:\\Windows", "windir=C:\\Windows", "=C:=C:\\", "TEMP=C:\\Temp", "ComSpec=cmd.exe"}
+	tests := []struct {
+		name      string
+		overrides map[string]string
+		want      []string
+	}{
+		{"no overrides", nil, []string{"ComSpec=cmd.exe", "Path=C:\\Windows", "TEMP=C:\\Temp", "windir=C:\\Windows"}},
+		{"override ignores case", map[string]string{"PATH": "D:\\bin"}, []string{"ComSpec=cmd.exe", "PATH=D:\\bin", "TEMP=C:\\Temp", "windir=C:\\Windows"}},
+		{"new name sorts case-insensitively", map[string]string{"lang": "C", "Zed": "1"}, []string{"ComSpec=cmd.exe", "lang=C", "Path=C:\\Windows", "TEMP=C:\\Temp", "windir=C:\\Windows", "Zed=1"}},
+	}
+	for _, tt := range tests {
+		if got := mergeEnv(base, tt.overrides); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: mergeEnv = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
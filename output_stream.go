@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	// output is coalesced into frames of up to outputFrameMax bytes, emitted at
+	// most outputFrameDelay after the first byte arrived
+	outputFrameDelay = 10 * time.Millisecond
+	outputFrameMax   = 64 << 10
+
+	outputText   = ""       // UTF-8 text on term:data:<id>
+	outputBase64 = "base64" // raw bytes, base64-encoded, on term:data64:<id>
+)
+
+// evtWriter implements io.Writer to forward SSH output to frontend & recorder.
+// stdout and stderr share one writer, so frames keep their relative order.
+type evtWriter struct {
+	tm *TermManager
+	ss *sshSession
+
+	mu      sync.Mutex
+	pending []byte
+	held    bool // pending ends in a sequence already kept back once
+	timer   *time.Timer
+	recTail []byte // base64 mode: a UTF-8 sequence cut off at the end of the last frame, not yet recorded
+}
+
+func newEvtWriter(tm *TermManager, ss *sshSession) *evtWriter {
+	return &evtWriter{tm: tm, ss: ss}
+}
+
+func (w *evtWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	w.ss.bytesIn.Add(int64(len(p)))
+	if pw := w.ss.prompt.Load(); pw != nil {
+		pw.feed(p)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending = append(w.pending, p...)
+	w.held = false
+	if len(w.pending) >= outputFrameMax {
+		w.emitLocked(false)
+	}
+	if len(w.pending) > 0 && w.timer == nil {
+		w.timer = time.AfterFunc(outputFrameDelay, w.tick)
+	}
+	return len(p), nil
+}
+
+// tick emits the pending frame. A UTF-8 sequence cut off at the end is kept back
+// for one more frame, normally enough for the rest to arrive, then sent as is.
+func (w *evtWriter) tick() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timer = nil
+	w.emitLocked(w.held)
+	if len(w.pending) > 0 {
+		w.held = true
+		w.timer = time.AfterFunc(outputFrameDelay, w.tick)
+	}
+}
+
+// flush emits everything pending, e.g. once the stream has ended
+func (w *evtWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	w.emitLocked(true)
+	if len(w.recTail) > 0 {
+		w.tm.appendRecord(w.ss, string(w.recTail))
+		w.recTail = nil
+	}
+}
+
+func (w *evtWriter) emitLocked(final bool) {
+	if len(w.pending) == 0 {
+		return
+	}
+	if w.ss.binary.Load() {
+		off := w.ss.scrollback.write(w.pending)
+		runtime.EventsEmit(w.tm.ctx, "term:data64:"+w.ss.id, base64.StdEncoding.EncodeToString(w.pending), off)
+		var text string
+		text, w.recTail = recordText(w.recTail, w.pending)
+		w.tm.appendRecord(w.ss, text)
+		w.pending = w.pending[:0]
+		return
+	}
+	n := len(w.pending)
+	if !final {
+		n -= incompleteUTF8Tail(w.pending)
+	}
+	if n == 0 {
+		return
+	}
+	chunk := string(w.pending[:n])
+	off := w.ss.scrollback.write(w.pending[:n])
+	runtime.EventsEmit(w.tm.ctx, "term:data:"+w.ss.id, chunk, off)
+	w.tm.appendRecord(w.ss, chunk)
+	w.pending = append(w.pending[:0], w.pending[n:]...)
+}
+
+// recordText returns the text of tail+p for the recording, keeping back a UTF-8
+// sequence cut off at the end so a character split across frames is recorded whole
+func recordText(tail, p []byte) (string, []byte) {
+	b := append(tail, p...)
+	n := len(b) - incompleteUTF8Tail(b)
+	return string(b[:n]), append([]byte(nil), b[n:]...)
+}
+
+// incompleteUTF8Tail returns the length of a multibyte sequence cut off at the end of p
+func incompleteUTF8Tail(p []byte) int {
+	for i := 1; i <= utf8.UTFMax-1 && i <= len(p); i++ {
+		c := p[len(p)-i]
+		if utf8.RuneStart(c) {
+			if !utf8.FullRune(p[len(p)-i:]) {
+				return i
+			}
+			return 0
+		}
+	}
+	return 0
+}
+
+// SetOutputEncoding switches a session between UTF-8 text ("") and base64 ("base64") output
+func (tm *TermManager) SetOutputEncoding(id string, encoding string) error {
+	s, ok := tm.get(id)
+	if !ok {
+		return errors.New("session not found")
+	}
+	switch encoding {
+	case outputText, outputBase64:
+	default:
+		return fmt.Errorf("unsupported output encoding: %s", encoding)
+	}
+	s.binary.Store(encoding == outputBase64)
+	return nil
+}
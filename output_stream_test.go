@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestIncompleteUTF8Tail(t *testing.T) {
+	euro := "€"           // 3 bytes
+	emoji := "\U0001F600" // 4 bytes
+	tests := []struct {
+		name string
+		p    string
+		want int
+	}{
+		{"empty", "", 0},
+		{"ascii", "abc", 0},
+		{"complete 3-byte", "a" + euro, 0},
+		{"complete 4-byte", emoji, 0},
+		{"3-byte cut after 1", "a" + euro[:1], 1},
+		{"3-byte cut after 2", "a" + euro[:2], 2},
+		{"4-byte cut after 3", "ab" + emoji[:3], 3},
+		{"lead byte alone", euro[:1], 1},
+		{"stray continuation bytes", "a\x80\x80", 0},
+		{"too many continuation bytes", "\x80\x80\x80\x80", 0},
+	}
+	for _, tt := range tests {
+		if got := incompleteUTF8Tail([]byte(tt.p)); got != tt.want {
+			t.Errorf("%s: incompleteUTF8Tail(%q) = %d, want %d", tt.name, tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestRecordText(t *testing.T) {
+	euro := "€"
+	tests := []struct {
+		name       string
+		tail, p    string
+		text, rest string
+	}{
+		{"ascii", "", "ls\r\n", "ls\r\n", ""},
+		{"split keeps tail", "", "a" + euro[:2], "a", euro[:2]},
+		{"tail completed", euro[:2], euro[2:] + "b", euro + "b", ""},
+		{"binary passes through", "", "\xff\xfe\x00", "\xff\xfe\x00", ""},
+	}
+	for _, tt := range tests {
+		text, rest := recordText([]byte(tt.tail), []byte(tt.p))
+		if text != tt.text || string(rest) != tt.rest {
+			t.Errorf("%s: recordText = %q, %q; want %q, %q", tt.name, text, rest, tt.text, tt.rest)
+		}
+	}
+}
+
+func TestAppendRecordValidUTF8(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rec.md")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &sshSession{recOn: true, recFile: f}
+	tm := NewTermManager()
+	tm.appendRecord(s, "ok €\n")
+	tm.appendRecord(s, "\x1f\x8b\x08\xff\xfe binary")
+	f.Close()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !utf8.Valid(b) || !strings.Contains(string(b), "ok €") || !strings.Contains(string(b), "\uFFFD binary") {
+		t.Errorf("recording = %q, want valid UTF-8 with the text kept", b)
+	}
+}
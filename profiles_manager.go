@@ -21,24 +21,55 @@ import (
 
 // AuthInfo holds authentication data for a host profile
 type AuthInfo struct {
-    Type       string `json:"type"` // password | key
+    Type       string `json:"type"` // password | key | agent
     Password   string `json:"password,omitempty"`
     KeyPEM     string `json:"key_pem,omitempty"`
     Passphrase string `json:"passphrase,omitempty"`
+    CertPub    string `json:"cert_pub,omitempty"` // OpenSSH user certificate for KeyPEM
 }
 
+// Profile kinds; SSH profiles leave Kind empty
+const (
+    profileKindSerial = "serial"
+    profileKindTelnet = "telnet"
+)
+
 type HostProfile struct {
     ID           string `json:"id"`
     Name         string `json:"name"`
+    Kind         string `json:"kind,omitempty"` // "" (ssh) | serial | telnet
+    Serial       *SerialParams `json:"serial,omitempty"`
+    Telnet       *TelnetParams `json:"telnet,omitempty"`
     Host         string `json:"host"`
     Port         int    `json:"port"`
     Username     string `json:"username"`
     Auth         AuthInfo `json:"auth"`
     KeepAliveSec int    `json:"keepAliveSec,omitempty"`
     TimeoutSec   int    `json:"timeoutSec,omitempty"`
+    AutoReconnect     bool `json:"autoReconnect,omitempty"`
+    ReconnectMaxTries int  `json:"reconnectMaxTries,omitempty"`
+    ForwardAgent      string `json:"forwardAgent,omitempty"` // "" | system | vault
+    // session environment and commands typed after login, see SSHParams
+    Env               map[string]string `json:"env,omitempty"`
+    StartupCommands   []string `json:"startupCommands,omitempty"`
+    PromptPattern     string   `json:"promptPattern,omitempty"`
+    // SSH algorithm negotiation, see SSHParams
+    AlgorithmPreset   string   `json:"algorithmPreset,omitempty"`
+    KeyExchanges      []string `json:"keyExchanges,omitempty"`
+    Ciphers           []string `json:"ciphers,omitempty"`
+    MACs              []string `json:"macs,omitempty"`
+    HostKeyAlgorithms []string `json:"hostKeyAlgorithms,omitempty"`
+    OutputEncoding    string `json:"outputEncoding,omitempty"` // "" | base64
+    ScrollbackBytes   int    `json:"scrollbackBytes,omitempty"`
     Cols         int    `json:"cols,omitempty"`
     Rows         int    `json:"rows,omitempty"`
-    // optional proxyjump fields
+    // ProxyJump chain, dialed in order before the target
+    Jumps        []JumpHost `json:"jumps,omitempty"`
+    // outbound SOCKS5/HTTP proxy; nil uses the global proxy
+    Proxy        *ProxyConfig `json:"proxy,omitempty"`
+    // tunnels opened on every connect
+    Forwards     []ForwardSpec `json:"forwards,omitempty"`
+    // Deprecated: single-hop gateway of older profiles, migrated into Jumps on load
     GatewayHost string `json:"gatewayHost,omitempty"`
     GatewayPort int    `json:"gatewayPort,omitempty"`
     GatewayUser string `json:"gatewayUser,omitempty"`
@@ -58,6 +89,7 @@ func (pm *ProfilesManager) GetProfile(id string) (HostProfile, error) {
     if err != nil { return empty, err }
     var hf hostsFile
     if err := store.DecryptJSON(pm.masterKey, b, &hf); err != nil { return empty, err }
+    hf.migrate()
     for _, h := range hf.Hosts { if h.ID == id { return h, nil } }
     return empty, errors.New("not found")
 }
@@ -68,6 +100,7 @@ func (pm *ProfilesManager) DeleteProfile(id string) error {
     if err != nil { return err }
     var hf hostsFile
     if err := store.DecryptJSON(pm.masterKey, b, &hf); err != nil { return err }
+    hf.migrate()
     out := make([]HostProfile, 0, len(hf.Hosts))
     for _, h := range hf.Hosts { if h.ID != id { out = append(out, h) } }
     hf.Hosts = out
@@ -147,12 +180,14 @@ func (pm *ProfilesManager) ImportProfiles(path string, passphrase string) (int,
     if err != nil { return 0, err }
     var in hostsFile
     if err := json.Unmarshal(pt, &in); err != nil { return 0, err }
+    in.migrate()
     // merge into current
     pathLocal := store.HostsPath()
     var curr hostsFile
     if _, err := os.Stat(pathLocal); err == nil {
         if b2, err := os.ReadFile(pathLocal); err == nil {
             _ = store.DecryptJSON(pm.masterKey, b2, &curr)
+            curr.migrate()
         }
     } else {
         curr.Schema = "xgoterm_hosts@1"
@@ -182,6 +217,29 @@ type hostsFile struct {
 	Hosts     []HostProfile `json:"hosts"`
 }
 
+// migrateLegacyGateway converts the single-hop Gateway* fields of older profiles into Jumps
+func (h *HostProfile) migrateLegacyGateway() {
+	if h.GatewayHost == "" {
+		return
+	}
+	if len(h.Jumps) == 0 {
+		h.Jumps = []JumpHost{{
+			Host: h.GatewayHost,
+			Port: h.GatewayPort,
+			User: h.GatewayUser,
+			Auth: AuthInfo{Type: h.GatewayAuth, Password: h.GatewayPassword, KeyPEM: h.GatewayKeyPEM, Passphrase: h.GatewayPassphrase},
+		}}
+	}
+	h.GatewayHost, h.GatewayPort, h.GatewayUser, h.GatewayAuth = "", 0, "", ""
+	h.GatewayPassword, h.GatewayKeyPEM, h.GatewayPassphrase = "", "", ""
+}
+
+func (hf *hostsFile) migrate() {
+	for i := range hf.Hosts {
+		hf.Hosts[i].migrateLegacyGateway()
+	}
+}
+
 type ProfilesManager struct {
 	ctx       context.Context
 	masterKey []byte
@@ -217,6 +275,7 @@ func (pm *ProfilesManager) ListProfiles() ([]HostProfile, error) {
 	if err != nil { return nil, err }
 	var hf hostsFile
 	if err := store.DecryptJSON(pm.masterKey, b, &hf); err != nil { return nil, err }
+	hf.migrate()
 	// sort by name then updated desc
 	sort.SliceStable(hf.Hosts, func(i, j int) bool {
 		if strings.EqualFold(hf.Hosts[i].Name, hf.Hosts[j].Name) {
@@ -228,10 +287,26 @@ func (pm *ProfilesManager) ListProfiles() ([]HostProfile, error) {
 }
 
 func (pm *ProfilesManager) SaveProfile(p HostProfile) (string, error) {
-	if p.Host == "" || p.Username == "" { return "", errors.New("host/username required") }
-	if p.Port == 0 { p.Port = 22 }
+	switch p.Kind {
+	case "":
+		if p.Host == "" || p.Username == "" { return "", errors.New("host/username required") }
+		if p.Port == 0 { p.Port = 22 }
+	case profileKindSerial:
+		if p.Serial == nil || p.Serial.Device == "" { return "", errors.New("serial device required") }
+		if p.Host == "" { p.Host = p.Serial.Device }
+	case profileKindTelnet:
+		if p.Telnet == nil || p.Telnet.Host == "" { return "", errors.New("host required") }
+		if p.Telnet.Port == 0 { p.Telnet.Port = 23 }
+		p.Host, p.Port = p.Telnet.Host, p.Telnet.Port
+	default:
+		return "", errors.New("unknown profile kind: " + p.Kind)
+	}
+	for _, f := range p.Forwards {
+		if err := f.validate(); err != nil { return "", err }
+	}
 	if p.Name == "" { p.Name = p.Host }
 	if p.ID == "" { p.ID = uuid.NewString() }
+	p.migrateLegacyGateway()
 	p.UpdatedAt = time.Now().Format(time.RFC3339)
 	// read existing
 	path := store.HostsPath()
@@ -240,6 +315,7 @@ func (pm *ProfilesManager) SaveProfile(p HostProfile) (string, error) {
 		b, err := os.ReadFile(path)
 		if err != nil { return "", err }
 		if err := store.DecryptJSON(pm.masterKey, b, &hf); err != nil { return "", err }
+		hf.migrate()
 	} else {
 		hf.Schema = "xgoterm_hosts@1"
 	}
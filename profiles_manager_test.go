@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestMigrateLegacyGateway(t *testing.T) {
+	legacy := `{"schema":"v1","hosts":[
+		{"id":"1","host":"db","username":"ops","gatewayHost":"bastion","gatewayPort":2222,"gatewayUser":"jump",
+		 "gatewayAuth":"key","gatewayKeyPEM":"PEM","gatewayPassphrase":"pp"},
+		{"id":"2","host":"web","username":"ops","gatewayHost":"old","jumps":[{"host":"new","user":"u","auth":{"type":"agent"}}]},
+		{"id":"3","host":"direct","username":"ops"}]}`
+	var hf hostsFile
+	if err := json.Unmarshal([]byte(legacy), &hf); err != nil {
+		t.Fatal(err)
+	}
+	hf.migrate()
+
+	want := [][]JumpHost{
+		{{Host: "bastion", Port: 2222, User: "jump", Auth: AuthInfo{Type: "key", KeyPEM: "PEM", Passphrase: "pp"}}},
+		{{Host: "new", User: "u", Auth: AuthInfo{Type: "agent"}}}, // an existing chain wins over the legacy fields
+		nil,
+	}
+	for i, h := range hf.Hosts {
+		if !reflect.DeepEqual(h.Jumps, want[i]) {
+			t.Errorf("host %s: Jumps = %+v, want %+v", h.ID, h.Jumps, want[i])
+		}
+	}
+
+	before := hf.Hosts[0].Jumps
+	hf.migrate()
+	if !reflect.DeepEqual(hf.Hosts[0].Jumps, before) {
+		t.Error("migrating twice changed the chain")
+	}
+	b, err := json.Marshal(hf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "gateway") {
+		t.Errorf("migrated file still has gateway fields: %s", b)
+	}
+}
+
+func TestJumpHostAddr(t *testing.T) {
+	tests := []struct {
+		j    JumpHost
+		want string
+	}{
+		{JumpHost{Host: "bastion"}, "bastion:22"},
+		{JumpHost{Host: "bastion", Port: 2222}, "bastion:2222"},
+		{JumpHost{Host: "fe80::1"}, "[fe80::1]:22"},
+	}
+	for _, tt := range tests {
+		if got := tt.j.addr(); got != tt.want {
+			t.Errorf("%+v.addr() = %q, want %q", tt.j, got, tt.want)
+		}
+	}
+}
+
+func TestDialChainRejectsIncompleteHop(t *testing.T) {
+	tm := NewTermManager()
+	for _, chain := range [][]JumpHost{{{Host: "bastion"}}, {{User: "u"}}} {
+		if _, _, err := tm.dialChain(chain, "db:22", hopConfig(&ssh.ClientConfig{}, "ops"), ProxyConfig{}); err == nil || !strings.Contains(err.Error(), "#1") {
+			t.Errorf("dialChain(%+v) err = %v, want a hop #1 error", chain, err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// promptTimeout bounds how long a connection waits for the user to answer a prompt
+const promptTimeout = 5 * time.Minute
+
+var errPromptCancelled = errors.New("cancelled by user")
+
+// promptReply carries the user's answer to a frontend prompt
+type promptReply struct {
+	accept  bool
+	answers []string
+}
+
+// askFrontend emits event with payload and blocks until the frontend answers promptID
+// (via one of the Confirm*/Answer* methods) or the prompt times out.
+func (tm *TermManager) askFrontend(promptID string, event string, payload any) (promptReply, error) {
+	ch := make(chan promptReply, 1)
+	tm.promptMu.Lock()
+	tm.prompts[promptID] = ch
+	tm.promptMu.Unlock()
+	defer func() {
+		tm.promptMu.Lock()
+		delete(tm.prompts, promptID)
+		tm.promptMu.Unlock()
+	}()
+
+	runtime.EventsEmit(tm.ctx, event, payload)
+	select {
+	case r := <-ch:
+		return r, nil
+	case <-time.After(promptTimeout):
+		return promptReply{}, fmt.Errorf("no answer within %s", promptTimeout)
+	}
+}
+
+// answerPrompt delivers a reply to a pending prompt
+func (tm *TermManager) answerPrompt(promptID string, r promptReply) error {
+	tm.promptMu.Lock()
+	ch, ok := tm.prompts[promptID]
+	tm.promptMu.Unlock()
+	if !ok {
+		return errors.New("prompt not found")
+	}
+	select {
+	case ch <- r:
+	default:
+	}
+	return nil
+}
+
+// CancelPrompt rejects any pending prompt, aborting the connection that is waiting on it
+func (tm *TermManager) CancelPrompt(promptID string) error {
+	return tm.answerPrompt(promptID, promptReply{accept: false})
+}
+
+func newPromptID() string {
+	return fmt.Sprintf("prompt-%d", time.Now().UnixNano())
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"xgoterm/internal/store"
+)
+
+// ProxyConfig is an outbound proxy used for the first TCP connection of a dial
+// (the target, or the first ProxyJump hop).
+type ProxyConfig struct {
+	Type     string `json:"type"` // "" (use global) | none | socks5 | http
+	Host     string `json:"host,omitempty"`
+	Port     int    `json:"port,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+func (c ProxyConfig) enabled() bool {
+	return (c.Type == "socks5" || c.Type == "http") && c.Host != ""
+}
+
+func (c ProxyConfig) addr() string {
+	port := c.Port
+	if port == 0 {
+		if c.Type == "http" {
+			port = 8080
+		} else {
+			port = 1080
+		}
+	}
+	return net.JoinHostPort(c.Host, strconv.Itoa(port))
+}
+
+// GetGlobalProxy returns the proxy applied to profiles that don't set their own
+func (tm *TermManager) GetGlobalProxy() ProxyConfig {
+	tm.proxyMu.Lock()
+	defer tm.proxyMu.Unlock()
+	return tm.globalProxy
+}
+
+// SetGlobalProxy stores the global proxy (encrypted, it may carry credentials)
+func (tm *TermManager) SetGlobalProxy(c ProxyConfig) error {
+	switch c.Type {
+	case "", "none", "socks5", "http":
+	default:
+		return fmt.Errorf("unsupported proxy type: %s", c.Type)
+	}
+	enc, err := store.EncryptJSON(tm.masterKey, c)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(store.ProxyPath(), enc, 0o600); err != nil {
+		return err
+	}
+	tm.proxyMu.Lock()
+	tm.globalProxy = c
+	tm.proxyMu.Unlock()
+	return nil
+}
+
+func (tm *TermManager) loadGlobalProxy() {
+	b, err := os.ReadFile(store.ProxyPath())
+	if err != nil {
+		return
+	}
+	var c ProxyConfig
+	if err := store.DecryptJSON(tm.masterKey, b, &c); err != nil {
+		log.Printf("[Proxy] failed to load global proxy: %v", err)
+		return
+	}
+	tm.proxyMu.Lock()
+	tm.globalProxy = c
+	tm.proxyMu.Unlock()
+}
+
+// effectiveProxy resolves a profile's proxy against the global one
+func (tm *TermManager) effectiveProxy(c ProxyConfig) ProxyConfig {
+	if c.Type == "" {
+		return tm.GetGlobalProxy()
+	}
+	return c
+}
+
+// dialTCP opens a TCP connection to addr, through proxy when one is enabled
+func dialTCP(proxy ProxyConfig, addr string, timeout time.Duration) (net.Conn, error) {
+	if !proxy.enabled() {
+		return net.DialTimeout("tcp", addr, timeout)
+	}
+	conn, err := net.DialTimeout("tcp", proxy.addr(), timeout)
+	if err != nil {
+		return nil, fmt.Errorf("connect %s proxy %s: %w", proxy.Type, proxy.addr(), err)
+	}
+	if timeout > 0 {
+		_ = conn.SetDeadline(time.Now().Add(timeout))
+	}
+	if proxy.Type == "http" {
+		err = httpConnect(conn, proxy, addr)
+	} else {
+		err = socks5Connect(conn, proxy, addr)
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("%s proxy %s: %w", proxy.Type, proxy.addr(), err)
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// httpConnect asks an HTTP proxy to tunnel to addr (RFC 7231 CONNECT)
+func httpConnect(conn net.Conn, proxy ProxyConfig, addr string) error {
+	req := "CONNECT " + addr + " HTTP/1.1\r\nHost: " + addr + "\r\n"
+	if proxy.Username != "" {
+		cred := base64.StdEncoding.EncodeToString([]byte(proxy.Username + ":" + proxy.Password))
+		req += "Proxy-Authorization: Basic " + cred + "\r\n"
+	}
+	if _, err := io.WriteString(conn, req+"\r\n"); err != nil {
+		return err
+	}
+	// read the header one byte at a time so nothing after it (SSH banner) is consumed
+	var head []byte
+	b := make([]byte, 1)
+	for !bytes.HasSuffix(head, []byte("\r\n\r\n")) {
+		if len(head) > 8192 {
+			return errors.New("CONNECT response header too long")
+		}
+		if _, err := io.ReadFull(conn, b); err != nil {
+			return err
+		}
+		head = append(head, b[0])
+	}
+	status, _, _ := strings.Cut(string(head), "\r\n")
+	f := strings.Fields(status)
+	if len(f) < 2 || !strings.HasPrefix(f[0], "HTTP/") {
+		return fmt.Errorf("malformed CONNECT response: %q", status)
+	}
+	if f[1] != "200" {
+		return fmt.Errorf("CONNECT refused: %s", strings.Join(f[1:], " "))
+	}
+	return nil
+}
+
+// socks5Connect performs a SOCKS5 handshake (RFC 1928/1929) and CONNECT to addr
+func socks5Connect(conn net.Conn, proxy ProxyConfig, addr string) error {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return err
+	}
+
+	methods := []byte{0x00}
+	if proxy.Username != "" {
+		methods = []byte{0x00, 0x02}
+	}
+	if _, err := conn.Write(append([]byte{0x05, byte(len(methods))}, methods...)); err != nil {
+		return err
+	}
+	var reply [2]byte
+	if _, err := io.ReadFull(conn, reply[:]); err != nil {
+		return err
+	}
+	if reply[0] != 0x05 {
+		return errors.New("not a SOCKS5 proxy")
+	}
+	switch reply[1] {
+	case 0x00:
+	case 0x02:
+		if proxy.Username == "" {
+			return errors.New("proxy requires username/password")
+		}
+		if len(proxy.Username) > 255 || len(proxy.Password) > 255 {
+			return errors.New("proxy credentials too long")
+		}
+		msg := []byte{0x01, byte(len(proxy.Username))}
+		msg = append(msg, proxy.Username...)
+		msg = append(msg, byte(len(proxy.Password)))
+		msg = append(msg, proxy.Password...)
+		if _, err := conn.Write(msg); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(conn, reply[:]); err != nil {
+			return err
+		}
+		if reply[1] != 0x00 {
+			return errors.New("proxy authentication failed")
+		}
+	default:
+		return errors.New("proxy accepts none of our authentication methods")
+	}
+
+	req := []byte{0x05, 0x01, 0x00}
+	if ip := net.ParseIP(host); ip == nil {
+		if len(host) > 255 {
+			return errors.New("host name too long")
+		}
+		req = append(req, 0x03, byte(len(host)))
+		req = append(req, host...)
+	} else if ip4 := ip.To4(); ip4 != nil {
+		req = append(req, 0x01)
+		req = append(req, ip4...)
+	} else {
+		req = append(req, 0x04)
+		req = append(req, ip.To16()...)
+	}
+	req = binary.BigEndian.AppendUint16(req, uint16(port))
+	if _, err := conn.Write(req); err != nil {
+		return err
+	}
+
+	var head [4]byte
+	if _, err := io.ReadFull(conn, head[:]); err != nil {
+		return err
+	}
+	if head[1] != 0x00 {
+		return fmt.Errorf("CONNECT failed: %s", socks5Reply(head[1]))
+	}
+	// skip the bound address
+	var skip int
+	switch head[3] {
+	case 0x01:
+		skip = 4
+	case 0x04:
+		skip = 16
+	case 0x03:
+		var l [1]byte
+		if _, err := io.ReadFull(conn, l[:]); err != nil {
+			return err
+		}
+		skip = int(l[0])
+	default:
+		return errors.New("malformed proxy reply")
+	}
+	_, err = io.ReadFull(conn, make([]byte, skip+2))
+	return err
+}
+
+func socks5Reply(code byte) string {
+	switch code {
+	case 0x01:
+		return "general failure"
+	case 0x02:
+		return "connection not allowed by ruleset"
+	case 0x03:
+		return "network unreachable"
+	case 0x04:
+		return "host unreachable"
+	case 0x05:
+		return "connection refused"
+	case 0x06:
+		return "TTL expired"
+	case 0x07:
+		return "command not supported"
+	case 0x08:
+		return "address type not supported"
+	}
+	return fmt.Sprintf("error 0x%02x", code)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pipeProxy runs serve as the proxy end of a net.Pipe and returns the client end
+func pipeProxy(t *testing.T, serve func(net.Conn)) net.Conn {
+	t.Helper()
+	client, server := net.Pipe()
+	deadline := time.Now().Add(2 * time.Second)
+	_ = client.SetDeadline(deadline)
+	_ = server.SetDeadline(deadline)
+	go func() {
+		defer server.Close()
+		serve(server)
+	}()
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// socks5Server scripts a SOCKS5 proxy: it offers method, checks user:pass when
+// method is 0x02, records the CONNECT request and answers with code
+type socks5Server struct {
+	method byte
+	user   string
+	pass   string
+	code   byte
+	req    chan []byte
+}
+
+func (s *socks5Server) serve(c net.Conn) {
+	r := bufio.NewReader(c)
+	var greet [2]byte
+	if _, err := io.ReadFull(r, greet[:]); err != nil {
+		return
+	}
+	if _, err := io.ReadFull(r, make([]byte, greet[1])); err != nil {
+		return
+	}
+	if _, err := c.Write([]byte{0x05, s.method}); err != nil || s.method == 0xff {
+		return
+	}
+	if s.method == 0x02 {
+		var ver [2]byte
+		if _, err := io.ReadFull(r, ver[:]); err != nil {
+			return
+		}
+		user := make([]byte, ver[1])
+		io.ReadFull(r, user)
+		l, _ := r.ReadByte()
+		pass := make([]byte, l)
+		io.ReadFull(r, pass)
+		if string(user) != s.user || string(pass) != s.pass {
+			c.Write([]byte{0x01, 0x01})
+			return
+		}
+		c.Write([]byte{0x01, 0x00})
+	}
+	head := make([]byte, 4)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return
+	}
+	var addr []byte
+	switch head[3] {
+	case 0x01:
+		addr = make([]byte, 4)
+	case 0x04:
+		addr = make([]byte, 16)
+	case 0x03:
+		l, _ := r.ReadByte()
+		addr = make([]byte, 1+int(l))
+		addr[0] = l
+	}
+	off := 0
+	if head[3] == 0x03 {
+		off = 1
+	}
+	if _, err := io.ReadFull(r, addr[off:]); err != nil {
+		return
+	}
+	port := make([]byte, 2)
+	io.ReadFull(r, port)
+	s.req <- append(append(head[3:], addr...), port...)
+	c.Write([]byte{0x05, s.code, 0x00, 0x01, 10, 0, 0, 1, 0x04, 0x38})
+}
+
+func TestSocks5Connect(t *testing.T) {
+	tests := []struct {
+		name    string
+		srv     socks5Server
+		proxy   ProxyConfig
+		addr    string
+		wantReq []byte
+		wantErr string
+	}{
+		{"domain", socks5Server{method: 0x00}, ProxyConfig{}, "db.example.com:22",
+			append(append([]byte{0x03, 14}, "db.example.com"...), 0x00, 0x16), ""},
+		{"ipv4", socks5Server{method: 0x00}, ProxyConfig{}, "10.1.2.3:2222", []byte{0x01, 10, 1, 2, 3, 0x08, 0xae}, ""},
+		{"ipv6", socks5Server{method: 0x00}, ProxyConfig{}, "[::1]:22",
+			[]byte{0x04, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0x00, 0x16}, ""},
+		{"auth", socks5Server{method: 0x02, user: "u", pass: "p"}, ProxyConfig{Username: "u", Password: "p"}, "1.2.3.4:22",
+			[]byte{0x01, 1, 2, 3, 4, 0x00, 0x16}, ""},
+		{"auth failure", socks5Server{method: 0x02, user: "u", pass: "p"}, ProxyConfig{Username: "u", Password: "bad"}, "1.2.3.4:22",
+			nil, "authentication failed"},
+		{"auth required", socks5Server{method: 0x02}, ProxyConfig{}, "1.2.3.4:22", nil, "requires username/password"},
+		{"no acceptable method", socks5Server{method: 0xff}, ProxyConfig{}, "1.2.3.4:22", nil, "none of our authentication methods"},
+		{"connect refused", socks5Server{method: 0x00, code: 0x05}, ProxyConfig{}, "1.2.3.4:22",
+			[]byte{0x01, 1, 2, 3, 4, 0x00, 0x16}, "connection refused"},
+	}
+	for _, tt := range tests {
+		srv := tt.srv
+		srv.req = make(chan []byte, 1)
+		conn := pipeProxy(t, srv.serve)
+		err := socks5Connect(conn, tt.proxy, tt.addr)
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.wantErr)
+			continue
+		}
+		if tt.wantReq == nil {
+			continue
+		}
+		select {
+		case req := <-srv.req:
+			if !bytes.Equal(req, tt.wantReq) {
+				t.Errorf("%s: CONNECT address = % x, want % x", tt.name, req, tt.wantReq)
+			}
+		default:
+			t.Errorf("%s: proxy saw no CONNECT request", tt.name)
+		}
+	}
+}
+
+func TestSocks5ConnectNotSocks(t *testing.T) {
+	conn := pipeProxy(t, func(c net.Conn) {
+		io.ReadFull(c, make([]byte, 3))
+		c.Write([]byte("HT"))
+	})
+	if err := socks5Connect(conn, ProxyConfig{}, "h:22"); err == nil || !strings.Contains(err.Error(), "not a SOCKS5") {
+		t.Errorf("err = %v, want not a SOCKS5 proxy", err)
+	}
+}
+
+func TestHTTPConnect(t *testing.T) {
+	tests := []struct {
+		name     string
+		proxy    ProxyConfig
+		reply    string
+		wantAuth string
+		wantErr  string
+	}{
+		{"ok", ProxyConfig{}, "HTTP/1.1 200 Connection established\r\n\r\n", "", ""},
+		{"basic auth", ProxyConfig{Username: "u", Password: "p"}, "HTTP/1.0 200 OK\r\nVia: x\r\n\r\n",
+			"Basic " + base64.StdEncoding.EncodeToString([]byte("u:p")), ""},
+		{"refused", ProxyConfig{}, "HTTP/1.1 403 Forbidden\r\n\r\n", "", "CONNECT refused: 403 Forbidden"},
+		{"auth required", ProxyConfig{}, "HTTP/1.1 407 Proxy Authentication Required\r\n\r\n", "", "407"},
+		{"malformed", ProxyConfig{}, "SSH-2.0-OpenSSH\r\n\r\n", "", "malformed CONNECT response"},
+	}
+	for _, tt := range tests {
+		got := make(chan string, 1)
+		conn := pipeProxy(t, func(c net.Conn) {
+			r := bufio.NewReader(c)
+			var head strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				head.WriteString(line)
+				if line == "\r\n" {
+					break
+				}
+			}
+			got <- head.String()
+			c.Write([]byte(tt.reply + "SSH-2.0-test\r\n"))
+		})
+		err := httpConnect(conn, tt.proxy, "db.example.com:22")
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.wantErr)
+			continue
+		}
+		req := <-got
+		if !strings.HasPrefix(req, "CONNECT db.example.com:22 HTTP/1.1\r\nHost: db.example.com:22\r\n") {
+			t.Errorf("%s: request = %q", tt.name, req)
+		}
+		hasAuth := strings.Contains(req, "Proxy-Authorization:")
+		if tt.wantAuth == "" && hasAuth || tt.wantAuth != "" && !strings.Contains(req, "Proxy-Authorization: "+tt.wantAuth+"\r\n") {
+			t.Errorf("%s: request = %q, want auth %q", tt.name, req, tt.wantAuth)
+		}
+		if err != nil {
+			continue
+		}
+		// the header must be consumed exactly, leaving the SSH banner for the handshake
+		banner, _ := bufio.NewReader(conn).ReadString('\n')
+		if banner != "SSH-2.0-test\r\n" {
+			t.Errorf("%s: left %q after the CONNECT response", tt.name, banner)
+		}
+	}
+}
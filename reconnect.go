@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	reconnectBaseDelay = time.Second
+	reconnectMaxDelay  = time.Minute
+	// keepalive used for dead-peer detection when auto reconnect is on but KeepAliveSec is 0
+	reconnectKeepAliveSec = 15
+)
+
+// ReconnectEvent is the payload of term:reconnecting and term:reconnected
+type ReconnectEvent struct {
+	ID             string   `json:"id"`
+	Attempt        int      `json:"attempt"`
+	DelayMs        int64    `json:"delayMs,omitempty"`        // wait before this attempt
+	Error          string   `json:"error,omitempty"`          // why the previous attempt failed
+	FailedForwards []string `json:"failedForwards,omitempty"` // forwards that could not be restarted
+}
+
+// shouldReconnect reports whether the shell ended because its connection dropped
+// (lost, from transportLost), as opposed to the shell exiting or the tab being closed,
+// and the profile opted in. A channel closed without an exit status is a normal
+// logout on many appliances, so only a dead transport counts.
+func (tm *TermManager) shouldReconnect(ss *sshSession, lost bool, waitErr error) bool {
+	if !ss.params.AutoReconnect || !lost {
+		return false
+	}
+	select {
+	case <-ss.quit:
+		return false
+	default:
+	}
+	log.Printf("[Reconnect] session %s lost its connection: %v", ss.id, waitErr)
+	return true
+}
+
+// reconnect redials ss with exponential backoff and reattaches a fresh shell under
+// the same session id. It returns false when the tab was closed or retries ran out.
+func (tm *TermManager) reconnect(ss *sshSession) bool {
+	tm.suspendForwards(ss)
+
+	// make sure the pool has dropped the dead connection, or acquireConn would hand it back
+	if old := ss.sshConn(); old != nil {
+		select {
+		case <-old.dead:
+		case <-ss.quit:
+			return false
+		case <-time.After(5 * time.Second):
+		}
+	}
+
+	delay := reconnectBaseDelay
+	var lastErr error
+	for attempt := 1; canRetry(attempt, ss.params.ReconnectMaxTries); attempt++ {
+		ev := ReconnectEvent{ID: ss.id, Attempt: attempt, DelayMs: delay.Milliseconds()}
+		if lastErr != nil {
+			ev.Error = lastErr.Error()
+		}
+		tm.emitReconnect("term:reconnecting", ev)
+		select {
+		case <-ss.quit:
+			return false
+		case <-time.After(delay):
+		}
+		if lastErr = tm.reattach(ss); lastErr == nil {
+			failed := tm.resumeForwards(ss)
+			log.Printf("[Reconnect] session %s reconnected after %d attempt(s)", ss.id, attempt)
+			tm.emitReconnect("term:reconnected", ReconnectEvent{ID: ss.id, Attempt: attempt, FailedForwards: failed})
+			return true
+		}
+		log.Printf("[Reconnect] session %s attempt %d failed: %v", ss.id, attempt, lastErr)
+		delay = nextReconnectDelay(delay)
+	}
+	log.Printf("[Reconnect] session %s giving up: %v", ss.id, lastErr)
+	return false
+}
+
+// canRetry reports whether attempt (from 1) is allowed by ReconnectMaxTries, 0 being unlimited
+func canRetry(attempt, maxTries int) bool {
+	return maxTries <= 0 || attempt <= maxTries
+}
+
+// nextReconnectDelay doubles delay up to reconnectMaxDelay
+func nextReconnectDelay(delay time.Duration) time.Duration {
+	return min(delay*2, reconnectMaxDelay)
+}
+
+// reattach opens a new connection and shell for ss at its last PTY size and swaps them in
+func (tm *TermManager) reattach(ss *sshSession) error {
+	conn, err := tm.acquireConn(context.Background(), ss.params)
+	if err != nil {
+		return err
+	}
+	sess, stdin, stdout, stderr, err := newShellSession(conn.client)
+	if err != nil {
+		tm.releaseConn(conn)
+		return err
+	}
+	if ss.params.ForwardAgent != "" {
+		if err := tm.enableAgentForwarding(conn, sess, ss.params); err != nil {
+			_ = sess.Close()
+			tm.releaseConn(conn)
+			return err
+		}
+	}
+	ss.mu.Lock()
+	cols, rows := ss.cols, ss.rows
+	ss.mu.Unlock()
+	if err := startShell(sess, cols, rows, ss.params.Env); err != nil {
+		_ = sess.Close()
+		tm.releaseConn(conn)
+		return err
+	}
+
+	ss.mu.Lock()
+	select {
+	case <-ss.quit:
+		ss.mu.Unlock()
+		_ = sess.Close()
+		tm.releaseConn(conn)
+		return errors.New("session closed")
+	default:
+	}
+	oldSess, oldConn := ss.sess, ss.conn
+	ss.client, ss.sess, ss.stdin, ss.stdout, ss.stderr, ss.conn = conn.client, sess, stdin, stdout, stderr, conn
+	ss.mu.Unlock()
+
+	_ = oldSess.Close()
+	tm.releaseConn(oldConn)
+	// the new shell starts from scratch, so replay cd/sudo etc.
+	tm.runStartup(ss, stdin)
+	return nil
+}
+
+// suspendForwards closes the listeners of ss while it is disconnected
+func (tm *TermManager) suspendForwards(ss *sshSession) {
+	ss.fwdMu.Lock()
+	defer ss.fwdMu.Unlock()
+	for _, f := range ss.forwards {
+		f.stopNow()
+	}
+}
+
+// resumeForwards reopens every registered forward of ss. Forwards whose port can
+// no longer be bound are dropped and returned.
+func (tm *TermManager) resumeForwards(ss *sshSession) []string {
+	ss.fwdMu.Lock()
+	defer ss.fwdMu.Unlock()
+	select {
+	case <-ss.quit:
+		return nil
+	default:
+	}
+	var failed []string
+	for id, f := range ss.forwards {
+		if err := tm.serveForward(ss, f); err != nil {
+			log.Printf("[Reconnect] failed to restart forward %s (%s -> %s): %v", id, f.laddr, f.raddr, err)
+			delete(ss.forwards, id)
+			failed = append(failed, id)
+		}
+	}
+	return failed
+}
+
+func (tm *TermManager) emitReconnect(event string, ev ReconnectEvent) {
+	runtime.EventsEmit(tm.ctx, event+":"+ev.ID, ev)
+	runtime.EventsEmit(tm.ctx, event, ev)
+}
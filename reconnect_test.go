@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestShouldReconnect(t *testing.T) {
+	tm := NewTermManager()
+	tests := []struct {
+		name   string
+		opted  bool
+		lost   bool
+		closed bool
+		want   bool
+	}{
+		{name: "transport dropped", opted: true, lost: true, want: true},
+		{name: "not opted in", lost: true},
+		{name: "logout without exit status", opted: true},
+		{name: "tab closed", opted: true, lost: true, closed: true},
+	}
+	for _, tt := range tests {
+		ss := &sshSession{id: "s", quit: make(chan struct{}), params: SSHParams{AutoReconnect: tt.opted}}
+		if tt.closed {
+			close(ss.quit)
+		}
+		if got := tm.shouldReconnect(ss, tt.lost, &ssh.ExitMissingError{}); got != tt.want {
+			t.Errorf("%s: shouldReconnect = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReconnectBackoff(t *testing.T) {
+	var got []time.Duration
+	for d, i := reconnectBaseDelay, 0; i < 9; i, d = i+1, nextReconnectDelay(d) {
+		got = append(got, d)
+	}
+	want := []time.Duration{1, 2, 4, 8, 16, 32, 60, 60, 60}
+	for i := range want {
+		if got[i] != want[i]*time.Second {
+			t.Fatalf("delays = %v, want %v seconds", got, want)
+		}
+	}
+}
+
+func TestCanRetry(t *testing.T) {
+	tests := []struct {
+		attempt, max int
+		want         bool
+	}{
+		{1, 0, true},
+		{1000, 0, true},
+		{1, 3, true},
+		{3, 3, true},
+		{4, 3, false},
+		{2, 1, false},
+	}
+	for _, tt := range tests {
+		if got := canRetry(tt.attempt, tt.max); got != tt.want {
+			t.Errorf("canRetry(%d, %d) = %v, want %v", tt.attempt, tt.max, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// StartRemoteForward starts R-forward on a session: the server listens on
+// remoteHost:remotePort and every connection it accepts is relayed to
+// localHost:localPort on this machine. remotePort 0 lets the server pick a
+// port. Returns forward id.
+func (tm *TermManager) StartRemoteForward(id string, remoteHost string, remotePort int, localHost string, localPort int) (string, error) {
+	s, ok := tm.getSSH(id)
+	if !ok {
+		return "", errors.New("session not found")
+	}
+	if remoteHost == "" {
+		remoteHost = "127.0.0.1" // like ssh -R, only reachable from the server itself
+	}
+	if localHost == "" {
+		localHost = "127.0.0.1"
+	}
+	laddr := net.JoinHostPort(remoteHost, strconv.Itoa(remotePort))
+	raddr := net.JoinHostPort(localHost, strconv.Itoa(localPort))
+	f := &portForward{id: fmt.Sprintf("fwd-%d", time.Now().UnixNano()), kind: forwardRemote, laddr: laddr, raddr: raddr}
+	if err := tm.serveForward(s, f); err != nil {
+		return "", fmt.Errorf("remote listen on %s: %w", laddr, err)
+	}
+	if a, ok := f.ln.Addr().(*net.TCPAddr); ok && remotePort == 0 {
+		// keep the assigned port so a reconnect asks for the same one
+		f.laddr = net.JoinHostPort(remoteHost, strconv.Itoa(a.Port))
+	}
+	s.fwdMu.Lock()
+	s.forwards[f.id] = f
+	s.fwdMu.Unlock()
+	return f.id, nil
+}
+
+func (tm *TermManager) StopRemoteForward(id string, forwardId string) error {
+	return tm.stopForward(id, forwardId, forwardRemote)
+}
+
+// remoteForwards dispatches the forwarded-tcpip channels of one connection to the
+// remote listeners opened on it, by the port the server bound (RFC 4254 7.2)
+type remoteForwards struct {
+	mu        sync.Mutex
+	listeners map[uint32]*remoteListener
+}
+
+// listenRemote asks the server behind conn to listen on addr. Unlike
+// ssh.Client.Listen it sends the host exactly as given, so names like
+// "localhost" or "0.0.0.0" mean what they mean to the server, not to this machine.
+func (tm *TermManager) listenRemote(conn *sharedConn, addr string) (net.Listener, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %q", portStr)
+	}
+
+	tm.connMu.Lock()
+	rf := conn.remote
+	if rf == nil {
+		chans := conn.client.HandleChannelOpen("forwarded-tcpip")
+		if chans == nil {
+			tm.connMu.Unlock()
+			return nil, errors.New("forwarded-tcpip channels are already handled")
+		}
+		rf = &remoteForwards{listeners: make(map[uint32]*remoteListener)}
+		conn.remote = rf
+		go rf.dispatch(chans)
+	}
+	tm.connMu.Unlock()
+
+	req := tcpipForward{Host: host, Port: uint32(port)}
+	ok, resp, err := conn.client.SendRequest("tcpip-forward", true, ssh.Marshal(&req))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("server refused the port forwarding request")
+	}
+	if req.Port == 0 {
+		var bound struct{ Port uint32 }
+		if err := ssh.Unmarshal(resp, &bound); err != nil {
+			return nil, fmt.Errorf("parse tcpip-forward reply: %w", err)
+		}
+		req.Port = bound.Port
+	}
+
+	l := &remoteListener{client: conn.client, forwards: rf, req: req, incoming: make(chan ssh.NewChannel, 16), closed: make(chan struct{})}
+	rf.mu.Lock()
+	if rf.listeners == nil {
+		rf.mu.Unlock()
+		l.cancel()
+		return nil, errors.New("connection closed")
+	}
+	if _, dup := rf.listeners[req.Port]; dup {
+		rf.mu.Unlock()
+		return nil, fmt.Errorf("remote port %d is already forwarded on this connection", req.Port)
+	}
+	rf.listeners[req.Port] = l
+	rf.mu.Unlock()
+	return l, nil
+}
+
+func (rf *remoteForwards) dispatch(chans <-chan ssh.NewChannel) {
+	for nc := range chans {
+		var msg forwardedTCPIP
+		if err := ssh.Unmarshal(nc.ExtraData(), &msg); err != nil {
+			_ = nc.Reject(ssh.ConnectionFailed, "malformed forwarded-tcpip request")
+			continue
+		}
+		rf.mu.Lock()
+		l := rf.listeners[msg.Port]
+		delivered := l != nil && l.deliver(nc)
+		rf.mu.Unlock()
+		if !delivered {
+			_ = nc.Reject(ssh.Prohibited, fmt.Sprintf("no forward for port %d", msg.Port))
+		}
+	}
+	// the connection is gone: end every Accept
+	rf.mu.Lock()
+	ls := rf.listeners
+	rf.listeners = nil
+	rf.mu.Unlock()
+	for _, l := range ls {
+		l.shut()
+	}
+}
+
+// tcpipForward is the payload of tcpip-forward and cancel-tcpip-forward requests
+type tcpipForward struct {
+	Host string
+	Port uint32
+}
+
+// forwardedTCPIP is the extra data of a forwarded-tcpip channel open
+type forwardedTCPIP struct {
+	Host       string
+	Port       uint32
+	OriginHost string
+	OriginPort uint32
+}
+
+// remoteListener is a net.Listener for one tcpip-forward request
+type remoteListener struct {
+	client    *ssh.Client
+	forwards  *remoteForwards
+	req       tcpipForward
+	incoming  chan ssh.NewChannel
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// deliver queues nc for Accept without blocking the connection's other channels.
+// The caller holds forwards.mu, so a listener removed by Close gets nothing more.
+func (l *remoteListener) deliver(nc ssh.NewChannel) bool {
+	select {
+	case l.incoming <- nc:
+		return true
+	default:
+		return false // Accept has fallen far behind
+	}
+}
+
+// shut ends Accept and rejects the channels it did not take
+func (l *remoteListener) shut() {
+	l.closeOnce.Do(func() { close(l.closed) })
+	for {
+		select {
+		case nc := <-l.incoming:
+			_ = nc.Reject(ssh.ConnectionFailed, "forward closed")
+		default:
+			return
+		}
+	}
+}
+
+func (l *remoteListener) Accept() (net.Conn, error) {
+	for {
+		select {
+		case nc := <-l.incoming:
+			ch, reqs, err := nc.Accept()
+			if err != nil {
+				continue
+			}
+			go ssh.DiscardRequests(reqs)
+			var msg forwardedTCPIP
+			_ = ssh.Unmarshal(nc.ExtraData(), &msg)
+			return &channelConn{Channel: ch, laddr: l.Addr(), raddr: tcpAddr(msg.OriginHost, msg.OriginPort)}, nil
+		case <-l.closed:
+			return nil, io.EOF
+		}
+	}
+}
+
+// Close cancels the forward on the server; connections already accepted stay open
+func (l *remoteListener) Close() error {
+	l.forwards.mu.Lock()
+	owned := l.forwards.listeners[l.req.Port] == l
+	if owned {
+		delete(l.forwards.listeners, l.req.Port)
+	}
+	l.forwards.mu.Unlock()
+	l.shut()
+	if !owned {
+		return nil // already closed, or the connection is gone
+	}
+	return l.cancel()
+}
+
+func (l *remoteListener) cancel() error {
+	ok, _, err := l.client.SendRequest("cancel-tcpip-forward", true, ssh.Marshal(&l.req))
+	if err == nil && !ok {
+		err = errors.New("server refused to cancel the port forwarding")
+	}
+	return err
+}
+
+func (l *remoteListener) Addr() net.Addr { return tcpAddr(l.req.Host, l.req.Port) }
+
+func tcpAddr(host string, port uint32) *net.TCPAddr {
+	return &net.TCPAddr{IP: net.ParseIP(host), Port: int(port)}
+}
+
+// channelConn is a net.Conn over a forwarded-tcpip channel
+type channelConn struct {
+	ssh.Channel
+	laddr, raddr net.Addr
+}
+
+func (c *channelConn) LocalAddr() net.Addr  { return c.laddr }
+func (c *channelConn) RemoteAddr() net.Addr { return c.raddr }
+
+func (c *channelConn) SetDeadline(time.Time) error {
+	return errors.New("deadlines are not supported on forwarded channels")
+}
+
+func (c *channelConn) SetReadDeadline(t time.Time) error  { return c.SetDeadline(t) }
+func (c *channelConn) SetWriteDeadline(t time.Time) error { return c.SetDeadline(t) }
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// sshPair connects a client to an in-process server and returns both ends along
+// with the global requests the client sends
+func sshPair(t *testing.T) (*ssh.Client, *ssh.ServerConn, <-chan *ssh.Request) {
+	t.Helper()
+	hostKey, _ := newTestSigner(t)
+	scfg := &ssh.ServerConfig{NoClientAuth: true}
+	scfg.AddHostKey(hostKey)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	type result struct {
+		conn *ssh.ServerConn
+		reqs <-chan *ssh.Request
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		conn, chans, reqs, err := ssh.NewServerConn(c, scfg)
+		if err == nil {
+			go func() {
+				for nc := range chans {
+					_ = nc.Reject(ssh.Prohibited, "test server")
+				}
+			}()
+		}
+		done <- result{conn, reqs, err}
+	}()
+	client, err := ssh.Dial("tcp", l.Addr().String(), &ssh.ClientConfig{User: "u", HostKeyCallback: ssh.InsecureIgnoreHostKey()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := <-done
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+	t.Cleanup(func() { client.Close(); r.conn.Close() })
+	return client, r.conn, r.reqs
+}
+
+func TestListenRemote(t *testing.T) {
+	client, server, reqs := sshPair(t)
+	got := make(chan string, 4)
+	go func() {
+		for r := range reqs {
+			var req tcpipForward
+			_ = ssh.Unmarshal(r.Payload, &req)
+			got <- r.Type + " " + net.JoinHostPort(req.Host, "0")
+			if r.Type == "tcpip-forward" && req.Port == 0 {
+				_ = r.Reply(true, ssh.Marshal(&struct{ Port uint32 }{4242}))
+				continue
+			}
+			_ = r.Reply(true, nil)
+		}
+	}()
+
+	tm := NewTermManager()
+	conn := &sharedConn{client: client}
+	ln, err := tm.listenRemote(conn, "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the host goes to the server as written, not resolved here
+	if s := <-got; s != "tcpip-forward localhost:0" {
+		t.Errorf("request = %q", s)
+	}
+	if a := ln.Addr().(*net.TCPAddr); a.Port != 4242 {
+		t.Errorf("bound port = %d, want 4242", a.Port)
+	}
+	if _, err := tm.listenRemote(conn, "localhost:4242"); err == nil {
+		t.Error("second forward of the same port accepted")
+	}
+	<-got
+
+	// the server's OpenChannel waits for Accept
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		c, _ := ln.Accept()
+		accepted <- c
+	}()
+	ch, chReqs, err := server.OpenChannel("forwarded-tcpip", ssh.Marshal(&forwardedTCPIP{"localhost", 4242, "10.0.0.9", 5555}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	go ssh.DiscardRequests(chReqs)
+	go func() { _, _ = ch.Write([]byte("ping")); _ = ch.Close() }()
+	c := <-accepted
+	if c == nil {
+		t.Fatal("Accept failed")
+	}
+	b, _ := io.ReadAll(c)
+	if string(b) != "ping" || c.RemoteAddr().String() != "10.0.0.9:5555" {
+		t.Errorf("read %q from %s", b, c.RemoteAddr())
+	}
+
+	var oce *ssh.OpenChannelError
+	if _, _, err := server.OpenChannel("forwarded-tcpip", ssh.Marshal(&forwardedTCPIP{"localhost", 9999, "10.0.0.9", 5555})); !errors.As(err, &oce) || oce.Reason != ssh.Prohibited {
+		t.Errorf("channel for an unknown port: err = %v, want Prohibited", err)
+	}
+
+	if err := ln.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if s := <-got; s != "cancel-tcpip-forward localhost:0" {
+		t.Errorf("request = %q", s)
+	}
+	if _, err := ln.Accept(); err != io.EOF {
+		t.Errorf("Accept after Close: err = %v, want EOF", err)
+	}
+	if _, _, err := server.OpenChannel("forwarded-tcpip", ssh.Marshal(&forwardedTCPIP{"localhost", 4242, "10.0.0.9", 5555})); err == nil {
+		t.Error("channel for a closed forward accepted")
+	}
+}
+
+func TestListenRemoteRefused(t *testing.T) {
+	client, _, reqs := sshPair(t)
+	go func() {
+		for r := range reqs {
+			_ = r.Reply(false, nil)
+		}
+	}()
+	if _, err := NewTermManager().listenRemote(&sharedConn{client: client}, "0.0.0.0:8080"); err == nil {
+		t.Error("refused tcpip-forward reported as success")
+	}
+}
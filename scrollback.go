@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	defaultScrollbackBytes = 1 << 20
+	maxScrollbackBytes     = 64 << 20
+)
+
+// Scrollback is returned by GetScrollback
+type Scrollback struct {
+	Data      string `json:"data"`      // base64 of the raw output bytes
+	From      int64  `json:"from"`      // stream offset of the first byte in Data
+	Next      int64  `json:"next"`      // offset to pass to the next call; equals the end of the last frame
+	Truncated bool   `json:"truncated"` // output before From has been dropped from the buffer
+}
+
+// ringBuffer keeps the last len(buf) bytes of a stream. Offsets count every byte
+// ever written, so they only grow and survive wrap-around.
+type ringBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+	end int64 // offset just past the last written byte
+}
+
+func newRingBuffer(size int) *ringBuffer {
+	if size <= 0 {
+		size = defaultScrollbackBytes
+	}
+	return &ringBuffer{buf: make([]byte, min(size, maxScrollbackBytes))}
+}
+
+// write appends p and returns the stream offset after it
+func (r *ringBuffer) write(p []byte) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := len(r.buf)
+	if len(p) > n {
+		r.end += int64(len(p) - n)
+		p = p[len(p)-n:]
+	}
+	i := int(r.end % int64(n))
+	c := copy(r.buf[i:], p)
+	copy(r.buf, p[c:])
+	r.end += int64(len(p))
+	return r.end
+}
+
+// read returns the bytes from offset from on, starting later if they were overwritten
+func (r *ringBuffer) read(from int64) Scrollback {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := int64(len(r.buf))
+	start := max(r.end-n, 0)
+	out := Scrollback{From: from, Next: r.end}
+	if from < start {
+		out.From, out.Truncated = start, true
+	}
+	if out.From >= r.end {
+		out.From = r.end
+		return out
+	}
+	data := make([]byte, 0, r.end-out.From)
+	i, j := int(out.From%n), int(r.end%n)
+	if i < j {
+		data = append(data, r.buf[i:j]...)
+	} else {
+		data = append(append(data, r.buf[i:]...), r.buf[:j]...)
+	}
+	if out.Truncated {
+		// don't start in the middle of a multibyte character
+		for k := 0; k < utf8.UTFMax-1 && k < len(data) && !utf8.RuneStart(data[0]); k++ {
+			data = data[1:]
+			out.From++
+		}
+	}
+	out.Data = base64.StdEncoding.EncodeToString(data)
+	return out
+}
+
+// GetScrollback returns the buffered output of a session from fromOffset on, so a
+// re-mounted view can replay it. Live term:data/term:data64 events carry the
+// offset after their frame as a second argument; frames at or below Next are
+// already part of the result.
+func (tm *TermManager) GetScrollback(id string, fromOffset int64) (Scrollback, error) {
+	s, ok := tm.get(id)
+	if !ok {
+		return Scrollback{}, errors.New("session not found")
+	}
+	return s.scrollback.read(fromOffset), nil
+}
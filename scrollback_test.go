@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestNewRingBufferSize(t *testing.T) {
+	if n := len(newRingBuffer(0).buf); n != defaultScrollbackBytes {
+		t.Errorf("default size = %d, want %d", n, defaultScrollbackBytes)
+	}
+	if n := len(newRingBuffer(maxScrollbackBytes + 1).buf); n != maxScrollbackBytes {
+		t.Errorf("capped size = %d, want %d", n, maxScrollbackBytes)
+	}
+}
+
+func TestRingBuffer(t *testing.T) {
+	r := newRingBuffer(8)
+	if end := r.write([]byte("hello")); end != 5 {
+		t.Fatalf("write returned %d, want 5", end)
+	}
+	if end := r.write([]byte("world!")); end != 11 {
+		t.Fatalf("write returned %d, want 11", end)
+	}
+
+	tests := []struct {
+		name      string
+		from      int64
+		data      string
+		start     int64
+		truncated bool
+	}{
+		{"overwritten start", 0, "loworld!", 3, true},
+		{"oldest kept byte", 3, "loworld!", 3, false},
+		{"across the wrap", 5, "world!", 5, false},
+		{"last byte", 10, "!", 10, false},
+		{"at the end", 11, "", 11, false},
+		{"past the end", 20, "", 11, false},
+	}
+	for _, tt := range tests {
+		sb := r.read(tt.from)
+		data, err := base64.StdEncoding.DecodeString(sb.Data)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if string(data) != tt.data || sb.From != tt.start || sb.Next != 11 || sb.Truncated != tt.truncated {
+			t.Errorf("%s: read(%d) = %q from %d next %d truncated %v, want %q from %d next 11 truncated %v",
+				tt.name, tt.from, data, sb.From, sb.Next, sb.Truncated, tt.data, tt.start, tt.truncated)
+		}
+	}
+}
+
+func TestRingBufferWriteLargerThanBuffer(t *testing.T) {
+	r := newRingBuffer(4)
+	r.write([]byte("ab"))
+	if end := r.write([]byte("0123456789")); end != 12 {
+		t.Fatalf("write returned %d, want 12", end)
+	}
+	sb := r.read(0)
+	data, _ := base64.StdEncoding.DecodeString(sb.Data)
+	if string(data) != "6789" || sb.From != 8 || !sb.Truncated {
+		t.Errorf("read(0) = %q from %d truncated %v, want \"6789\" from 8 truncated", data, sb.From, sb.Truncated)
+	}
+}
+
+func TestRingBufferTruncatedUTF8(t *testing.T) {
+	r := newRingBuffer(4)
+	r.write([]byte("€€")) // 6 bytes, the first kept byte is a continuation byte
+	sb := r.read(0)
+	data, _ := base64.StdEncoding.DecodeString(sb.Data)
+	if string(data) != "€" || sb.From != 3 {
+		t.Errorf("read(0) = %q from %d, want %q from 3", data, sb.From, "€")
+	}
+}
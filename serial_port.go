@@ -0,0 +1,297 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+// SerialParams describes a serial console session. It can be tested without
+// hardware against a virtual null-modem pair: com0com is the Windows counterpart
+// of the pty pair socat creates elsewhere (socat pty,raw,echo=0 pty,raw,echo=0).
+type SerialParams struct {
+	Device      string `json:"device"`             // COM3, or \\.\COM10
+	BaudRate    int    `json:"baudRate"`           // default 9600
+	DataBits    int    `json:"dataBits,omitempty"` // 5-8, default 8
+	Parity      string `json:"parity,omitempty"`   // none | odd | even | mark | space
+	StopBits    string `json:"stopBits,omitempty"` // 1 | 1.5 | 2
+	FlowControl string `json:"flowControl,omitempty"`
+	// output kept for GetScrollback, 0 = 1 MiB
+	ScrollbackBytes int `json:"scrollbackBytes,omitempty"`
+}
+
+// Flow control modes for SerialParams.FlowControl
+const (
+	flowNone    = "none"
+	flowRTSCTS  = "rtscts"
+	flowXONXOFF = "xonxoff"
+)
+
+// DCB.Flags bits, see the DCB structure in winbase.h
+const (
+	dcbBinary      = 0x0001
+	dcbParity      = 0x0002
+	dcbOutxCtsFlow = 0x0004
+	dcbOutX        = 0x0100
+	dcbInX         = 0x0200
+)
+
+// serial reads return at least every serialPollMs so Close is noticed
+const serialPollMs = 200
+
+// StartSerial opens a serial port as a terminal session
+func (tm *TermManager) StartSerial(p SerialParams) (string, error) {
+	port, err := openSerial(p)
+	if err != nil {
+		return "", err
+	}
+	title := fmt.Sprintf("%s@%d", strings.TrimPrefix(p.Device, `\\.\`), port.baud)
+	return tm.startBackend(port, title, 0, 0, p.ScrollbackBytes)
+}
+
+// ListSerialPorts returns the serial ports present on this machine
+func (tm *TermManager) ListSerialPorts() ([]string, error) {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, `HARDWARE\DEVICEMAP\SERIALCOMM`, registry.QUERY_VALUE)
+	if err != nil {
+		if errors.Is(err, registry.ErrNotExist) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	defer k.Close()
+	names, err := k.ReadValueNames(0)
+	if err != nil {
+		return nil, err
+	}
+	ports := make([]string, 0, len(names))
+	for _, n := range names {
+		if v, _, err := k.GetStringValue(n); err == nil {
+			ports = append(ports, v)
+		}
+	}
+	sort.Strings(ports)
+	return ports, nil
+}
+
+// serialPort is an overlapped handle to a COM port
+type serialPort struct {
+	h    windows.Handle
+	baud int
+	// kept on the heap for the kernel; reads come from the pump only, writes are serialized by wmu
+	readOv  windows.Overlapped
+	writeOv windows.Overlapped
+	wmu     sync.Mutex
+
+	ioMu   sync.RWMutex // held shared during I/O, exclusively to close the handle
+	closed atomic.Bool
+	err    error // read error that ended the session
+}
+
+func openSerial(p SerialParams) (*serialPort, error) {
+	if p.Device == "" {
+		return nil, errors.New("serial device required")
+	}
+	dcb, err := serialDCB(p)
+	if err != nil {
+		return nil, err
+	}
+	path := p.Device
+	if !strings.HasPrefix(path, `\\`) {
+		path = `\\.\` + path // required for COM10 and above
+	}
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	h, err := windows.CreateFile(name, windows.GENERIC_READ|windows.GENERIC_WRITE, 0, nil, windows.OPEN_EXISTING, windows.FILE_FLAG_OVERLAPPED, 0)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", p.Device, err)
+	}
+	s := &serialPort{h: h, baud: int(dcb.BaudRate)}
+	fail := func(what string, err error) (*serialPort, error) {
+		s.release()
+		return nil, fmt.Errorf("%s %s: %w", what, p.Device, err)
+	}
+
+	var cur windows.DCB
+	cur.DCBlength = uint32(unsafe.Sizeof(windows.DCB{}))
+	if err := windows.GetCommState(h, &cur); err != nil {
+		return fail("read settings of", err)
+	}
+	dcb.XonChar, dcb.XoffChar = cur.XonChar, cur.XoffChar
+	if dcb.XonChar == 0 && dcb.XoffChar == 0 {
+		dcb.XonChar, dcb.XoffChar = 0x11, 0x13
+	}
+	dcb.XonLim, dcb.XoffLim = cur.XonLim, cur.XoffLim
+	if err := windows.SetCommState(h, dcb); err != nil {
+		return fail("configure", err)
+	}
+	// return as soon as any byte arrives, or after serialPollMs with none
+	timeouts := windows.CommTimeouts{
+		ReadIntervalTimeout:        windows.INFINITE,
+		ReadTotalTimeoutMultiplier: windows.INFINITE,
+		ReadTotalTimeoutConstant:   serialPollMs,
+	}
+	if err := windows.SetCommTimeouts(h, &timeouts); err != nil {
+		return fail("configure", err)
+	}
+	_ = windows.PurgeComm(h, windows.PURGE_RXCLEAR|windows.PURGE_TXCLEAR)
+	if s.readOv.HEvent, err = windows.CreateEvent(nil, 1, 0, nil); err != nil {
+		return fail("open", err)
+	}
+	if s.writeOv.HEvent, err = windows.CreateEvent(nil, 1, 0, nil); err != nil {
+		return fail("open", err)
+	}
+	return s, nil
+}
+
+// serialDCB translates p into a device control block
+func serialDCB(p SerialParams) (*windows.DCB, error) {
+	dcb := &windows.DCB{DCBlength: uint32(unsafe.Sizeof(windows.DCB{})), BaudRate: 9600, ByteSize: 8}
+	if p.BaudRate > 0 {
+		dcb.BaudRate = uint32(p.BaudRate)
+	}
+	if p.DataBits != 0 {
+		if p.DataBits < 5 || p.DataBits > 8 {
+			return nil, fmt.Errorf("unsupported data bits: %d", p.DataBits)
+		}
+		dcb.ByteSize = uint8(p.DataBits)
+	}
+	switch p.Parity {
+	case "", "none":
+		dcb.Parity = windows.NOPARITY
+	case "odd":
+		dcb.Parity = windows.ODDPARITY
+	case "even":
+		dcb.Parity = windows.EVENPARITY
+	case "mark":
+		dcb.Parity = windows.MARKPARITY
+	case "space":
+		dcb.Parity = windows.SPACEPARITY
+	default:
+		return nil, fmt.Errorf("unsupported parity: %s", p.Parity)
+	}
+	switch p.StopBits {
+	case "", "1":
+		dcb.StopBits = windows.ONESTOPBIT
+	case "1.5":
+		dcb.StopBits = windows.ONE5STOPBITS
+	case "2":
+		dcb.StopBits = windows.TWOSTOPBITS
+	default:
+		return nil, fmt.Errorf("unsupported stop bits: %s", p.StopBits)
+	}
+	dcb.Flags = dcbBinary | windows.DTR_CONTROL_ENABLE
+	if dcb.Parity != windows.NOPARITY {
+		dcb.Flags |= dcbParity
+	}
+	switch p.FlowControl {
+	case "", flowNone:
+		dcb.Flags |= windows.RTS_CONTROL_ENABLE
+	case flowRTSCTS:
+		dcb.Flags |= dcbOutxCtsFlow | windows.RTS_CONTROL_HANDSHAKE
+	case flowXONXOFF:
+		dcb.Flags |= dcbOutX | dcbInX | windows.RTS_CONTROL_ENABLE
+	default:
+		return nil, fmt.Errorf("unsupported flow control: %s", p.FlowControl)
+	}
+	return dcb, nil
+}
+
+// overlappedIO runs one read or write and waits for it to complete
+func (s *serialPort) overlappedIO(p []byte, write bool) (int, error) {
+	s.ioMu.RLock()
+	defer s.ioMu.RUnlock()
+	if s.closed.Load() {
+		return 0, io.EOF
+	}
+	ov := &s.readOv
+	if write {
+		ov = &s.writeOv
+	}
+	ov.Internal, ov.InternalHigh, ov.Offset, ov.OffsetHigh = 0, 0, 0, 0
+	var n uint32
+	var err error
+	if write {
+		err = windows.WriteFile(s.h, p, &n, ov)
+	} else {
+		err = windows.ReadFile(s.h, p, &n, ov)
+	}
+	if err != nil && err != windows.ERROR_IO_PENDING {
+		return 0, err
+	}
+	if err := windows.GetOverlappedResult(s.h, ov, &n, true); err != nil {
+		if s.closed.Load() {
+			return 0, io.EOF
+		}
+		return 0, err
+	}
+	return int(n), nil
+}
+
+func (s *serialPort) Read(p []byte) (int, error) {
+	for {
+		n, err := s.overlappedIO(p, false)
+		if err != nil {
+			if err != io.EOF {
+				s.err = err // e.g. the USB adapter was unplugged
+			}
+			return n, err
+		}
+		if n > 0 {
+			return n, nil
+		}
+		// read timeout with no data, poll again
+	}
+}
+
+func (s *serialPort) Write(p []byte) (int, error) {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	written := 0
+	for written < len(p) {
+		n, err := s.overlappedIO(p[written:], true)
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// a serial line has no window size
+func (s *serialPort) Start(cols, rows int) error  { return nil }
+func (s *serialPort) Resize(cols, rows int) error { return nil }
+
+func (s *serialPort) Wait() (int, error) {
+	return -1, s.err
+}
+
+func (s *serialPort) Close() error {
+	if s.closed.Swap(true) {
+		return nil
+	}
+	_ = windows.CancelIoEx(s.h, nil)
+	s.ioMu.Lock()
+	defer s.ioMu.Unlock()
+	s.release()
+	return nil
+}
+
+func (s *serialPort) release() {
+	windows.CloseHandle(s.h)
+	if s.readOv.HEvent != 0 {
+		windows.CloseHandle(s.readOv.HEvent)
+	}
+	if s.writeOv.HEvent != 0 {
+		windows.CloseHandle(s.writeOv.HEvent)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"testing"
+
+	"golang.org/x/sys/windows"
+)
+
+func TestSerialDCB(t *testing.T) {
+	const lines = dcbBinary | windows.DTR_CONTROL_ENABLE
+	tests := []struct {
+		name     string
+		p        SerialParams
+		baud     uint32
+		byteSize uint8
+		parity   uint8
+		stopBits uint8
+		flags    uint32
+	}{
+		{name: "defaults", baud: 9600, byteSize: 8, parity: windows.NOPARITY, stopBits: windows.ONESTOPBIT, flags: lines | windows.RTS_CONTROL_ENABLE},
+		{
+			name: "115200 7E1",
+			p:    SerialParams{BaudRate: 115200, DataBits: 7, Parity: "even", StopBits: "1"},
+			baud: 115200, byteSize: 7, parity: windows.EVENPARITY, stopBits: windows.ONESTOPBIT,
+			flags: lines | dcbParity | windows.RTS_CONTROL_ENABLE,
+		},
+		{
+			name: "odd parity, 2 stop bits",
+			p:    SerialParams{Parity: "odd", StopBits: "2"},
+			baud: 9600, byteSize: 8, parity: windows.ODDPARITY, stopBits: windows.TWOSTOPBITS,
+			flags: lines | dcbParity | windows.RTS_CONTROL_ENABLE,
+		},
+		{
+			name: "mark parity, 1.5 stop bits",
+			p:    SerialParams{DataBits: 5, Parity: "mark", StopBits: "1.5"},
+			baud: 9600, byteSize: 5, parity: windows.MARKPARITY, stopBits: windows.ONE5STOPBITS,
+			flags: lines | dcbParity | windows.RTS_CONTROL_ENABLE,
+		},
+		{
+			name: "space parity",
+			p:    SerialParams{Parity: "space"},
+			baud: 9600, byteSize: 8, parity: windows.SPACEPARITY, stopBits: windows.ONESTOPBIT,
+			flags: lines | dcbParity | windows.RTS_CONTROL_ENABLE,
+		},
+		{
+			name: "explicit none",
+			p:    SerialParams{Parity: "none", FlowControl: flowNone},
+			baud: 9600, byteSize: 8, parity: windows.NOPARITY, stopBits: windows.ONESTOPBIT,
+			flags: lines | windows.RTS_CONTROL_ENABLE,
+		},
+		{
+			name: "RTS/CTS",
+			p:    SerialParams{FlowControl: flowRTSCTS},
+			baud: 9600, byteSize: 8, parity: windows.NOPARITY, stopBits: windows.ONESTOPBIT,
+			flags: lines | dcbOutxCtsFlow | windows.RTS_CONTROL_HANDSHAKE,
+		},
+		{
+			name: "XON/XOFF",
+			p:    SerialParams{FlowControl: flowXONXOFF},
+			baud: 9600, byteSize: 8, parity: windows.NOPARITY, stopBits: windows.ONESTOPBIT,
+			flags: lines | dcbOutX | dcbInX | windows.RTS_CONTROL_ENABLE,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dcb, err := serialDCB(tt.p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dcb.BaudRate != tt.baud || dcb.ByteSize != tt.byteSize || dcb.Parity != tt.parity || dcb.StopBits != tt.stopBits {
+				t.Errorf("got %d %d/%d/%d, want %d %d/%d/%d",
+					dcb.BaudRate, dcb.ByteSize, dcb.Parity, dcb.StopBits, tt.baud, tt.byteSize, tt.parity, tt.stopBits)
+			}
+			if dcb.Flags != tt.flags {
+				t.Errorf("flags = %#x, want %#x", dcb.Flags, tt.flags)
+			}
+		})
+	}
+}
+
+func TestSerialDCBInvalid(t *testing.T) {
+	for _, p := range []SerialParams{
+		{DataBits: 4},
+		{DataBits: 9},
+		{Parity: "Even"},
+		{StopBits: "3"},
+		{FlowControl: "dsrdtr"},
+	} {
+		if _, err := serialDCB(p); err == nil {
+			t.Errorf("serialDCB(%+v) succeeded, want an error", p)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Reasons reported in SessionClosed
+const (
+	closeReasonExit        = "exit"            // shell exited with a status
+	closeReasonSignal      = "signal"          // shell was killed by a signal
+	closeReasonExitMissing = "exit-missing"    // channel closed without an exit status
+	closeReasonLost        = "connection-lost" // transport failed
+	closeReasonClosed      = "closed"          // closed locally via Close
+)
+
+// SessionClosed is the payload of term:closed and term:closed:<id>
+type SessionClosed struct {
+	ID       string `json:"id"`
+	Reason   string `json:"reason"`
+	ExitCode int    `json:"exitCode"` // -1 when the server sent none
+	Signal   string `json:"signal,omitempty"`
+	Message  string `json:"message"`
+	BytesIn  int64  `json:"bytesIn"`  // output received from the server
+	BytesOut int64  `json:"bytesOut"` // input sent to the server
+}
+
+// transportGrace bounds how long transportLost waits for a dropped transport to be noticed
+const transportGrace = time.Second
+
+// transportLost reports whether the shell of ss ended with waitErr because its
+// connection died. Session.Wait cannot tell: the mux closes every channel when the
+// transport drops, so that looks like a channel closed without an exit status.
+// Instead wait briefly for client.Wait to return, which closes sharedConn.dead.
+func transportLost(ss *sshSession, waitErr error) bool {
+	var exitErr *ssh.ExitError
+	if waitErr == nil || errors.As(waitErr, &exitErr) {
+		return false
+	}
+	conn := ss.sshConn()
+	if conn == nil {
+		return false
+	}
+	select {
+	case <-conn.dead:
+		return true
+	case <-ss.quit:
+		return false
+	case <-time.After(transportGrace):
+		return false
+	}
+}
+
+// closeInfo classifies the error returned by ssh.Session.Wait for the ended shell of ss;
+// lost is the result of transportLost for that error
+func closeInfo(ss *sshSession, err error, lost bool) SessionClosed {
+	ev := SessionClosed{ID: ss.id, ExitCode: -1, BytesIn: ss.bytesIn.Load(), BytesOut: ss.bytesOut.Load()}
+	var exitErr *ssh.ExitError
+	var missing *ssh.ExitMissingError
+	select {
+	case <-ss.quit:
+		ev.Reason, ev.Message = closeReasonClosed, "会话已关闭"
+		return ev
+	default:
+	}
+	switch {
+	case err == nil:
+		ev.Reason, ev.ExitCode, ev.Message = closeReasonExit, 0, "进程已退出，退出码 0"
+	case errors.As(err, &exitErr) && exitErr.Signal() != "":
+		ev.Reason, ev.ExitCode, ev.Signal = closeReasonSignal, exitErr.ExitStatus(), exitErr.Signal()
+		ev.Message = fmt.Sprintf("进程被信号 SIG%s 终止", exitErr.Signal())
+		if exitErr.Msg() != "" {
+			ev.Message += "：" + exitErr.Msg()
+		}
+	case errors.As(err, &exitErr):
+		ev.Reason, ev.ExitCode = closeReasonExit, exitErr.ExitStatus()
+		ev.Message = fmt.Sprintf("进程已退出，退出码 %d", exitErr.ExitStatus())
+	case lost:
+		ev.Reason, ev.Message = closeReasonLost, "连接已断开"
+		if !errors.As(err, &missing) {
+			ev.Message += "：" + err.Error()
+		}
+	default:
+		ev.Reason, ev.Message = closeReasonExitMissing, "服务器关闭了会话，未返回退出状态"
+	}
+	return ev
+}
+
+// closeInfoBackend describes the end of a local, serial or telnet session
+func closeInfoBackend(ss *sshSession, code int, err error) SessionClosed {
+	ev := SessionClosed{ID: ss.id, ExitCode: -1, BytesIn: ss.bytesIn.Load(), BytesOut: ss.bytesOut.Load()}
+	select {
+	case <-ss.quit:
+		ev.Reason, ev.Message = closeReasonClosed, "会话已关闭"
+		return ev
+	default:
+	}
+	switch {
+	case err != nil:
+		ev.Reason, ev.Message = closeReasonLost, "连接已断开："+err.Error()
+	case code >= 0:
+		ev.Reason, ev.ExitCode = closeReasonExit, code
+		ev.Message = fmt.Sprintf("进程已退出，退出码 %d", code)
+	default:
+		ev.Reason, ev.Message = closeReasonExitMissing, "会话已结束"
+	}
+	return ev
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestCloseInfo(t *testing.T) {
+	missing := &ssh.ExitMissingError{}
+	tests := []struct {
+		name     string
+		closed   bool // closed locally via Close
+		err      error
+		lost     bool
+		reason   string
+		exitCode int
+	}{
+		{name: "clean exit", reason: closeReasonExit, exitCode: 0},
+		{name: "exit status", err: &ssh.ExitError{}, reason: closeReasonExit, exitCode: 0},
+		{name: "no exit status, transport alive", err: missing, reason: closeReasonExitMissing, exitCode: -1},
+		{name: "no exit status, transport gone", err: missing, lost: true, reason: closeReasonLost, exitCode: -1},
+		{name: "wrapped missing status", err: fmt.Errorf("wait: %w", missing), reason: closeReasonExitMissing, exitCode: -1},
+		{name: "I/O error on a dead transport", err: errors.New("EOF"), lost: true, reason: closeReasonLost, exitCode: -1},
+		{name: "closed locally", closed: true, err: missing, lost: true, reason: closeReasonClosed, exitCode: -1},
+	}
+	for _, tt := range tests {
+		ss := &sshSession{id: "s1", quit: make(chan struct{})}
+		ss.bytesIn.Store(10)
+		ss.bytesOut.Store(3)
+		if tt.closed {
+			close(ss.quit)
+		}
+		ev := closeInfo(ss, tt.err, tt.lost)
+		if ev.Reason != tt.reason || ev.ExitCode != tt.exitCode {
+			t.Errorf("%s: reason %q exit %d, want %q %d", tt.name, ev.Reason, ev.ExitCode, tt.reason, tt.exitCode)
+		}
+		if ev.ID != "s1" || ev.BytesIn != 10 || ev.BytesOut != 3 || ev.Message == "" {
+			t.Errorf("%s: incomplete event %+v", tt.name, ev)
+		}
+	}
+}
+
+func TestTransportLost(t *testing.T) {
+	dead := &sharedConn{dead: make(chan struct{})}
+	close(dead.dead)
+	alive := &sharedConn{dead: make(chan struct{})}
+	session := func(c *sharedConn) *sshSession { return &sshSession{quit: make(chan struct{}), conn: c} }
+
+	if transportLost(session(dead), nil) {
+		t.Error("clean exit reported as lost")
+	}
+	if transportLost(session(dead), &ssh.ExitError{}) {
+		t.Error("exit status reported as lost")
+	}
+	if !transportLost(session(dead), &ssh.ExitMissingError{}) {
+		t.Error("dead transport not reported")
+	}
+	if transportLost(session(nil), &ssh.ExitMissingError{}) {
+		t.Error("session without connection reported as lost")
+	}
+	closed := session(alive)
+	close(closed.quit)
+	if transportLost(closed, &ssh.ExitMissingError{}) {
+		t.Error("locally closed session reported as lost")
+	}
+	// waits transportGrace for the transport, then gives up
+	if transportLost(session(alive), &ssh.ExitMissingError{}) {
+		t.Error("live transport reported as lost")
+	}
+}
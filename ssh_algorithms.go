@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// algoPresetLegacy additionally enables the insecure algorithms old switches and
+// appliances still need (diffie-hellman-group1-sha1, aes128-cbc, ssh-rsa, ...)
+const algoPresetLegacy = "legacy"
+
+// applyAlgorithms sets the negotiation lists of cfg from p. Empty lists keep the
+// x/crypto defaults, or the preset's list when one is selected.
+func applyAlgorithms(cfg *ssh.ClientConfig, p SSHParams) error {
+	supported, insecure := ssh.SupportedAlgorithms(), ssh.InsecureAlgorithms()
+	switch p.AlgorithmPreset {
+	case "":
+	case algoPresetLegacy:
+		cfg.KeyExchanges = slices.Concat(supported.KeyExchanges, insecure.KeyExchanges)
+		cfg.Ciphers = slices.Concat(supported.Ciphers, insecure.Ciphers)
+		cfg.MACs = slices.Concat(supported.MACs, insecure.MACs)
+		cfg.HostKeyAlgorithms = slices.Concat(supported.HostKeys, insecure.HostKeys)
+	default:
+		return fmt.Errorf("unknown algorithm preset: %s", p.AlgorithmPreset)
+	}
+
+	lists := []struct {
+		what  string
+		names []string
+		known []string
+		dst   *[]string
+	}{
+		{"key exchange", p.KeyExchanges, slices.Concat(supported.KeyExchanges, insecure.KeyExchanges), &cfg.KeyExchanges},
+		{"cipher", p.Ciphers, slices.Concat(supported.Ciphers, insecure.Ciphers), &cfg.Ciphers},
+		{"MAC", p.MACs, slices.Concat(supported.MACs, insecure.MACs), &cfg.MACs},
+		{"host key", p.HostKeyAlgorithms, slices.Concat(supported.HostKeys, insecure.HostKeys), &cfg.HostKeyAlgorithms},
+	}
+	for _, l := range lists {
+		if len(l.names) == 0 {
+			continue
+		}
+		for _, n := range l.names {
+			if !slices.Contains(l.known, n) {
+				return fmt.Errorf("unsupported %s algorithm %q (supported: %s)", l.what, n, strings.Join(l.known, ", "))
+			}
+		}
+		*l.dst = l.names
+	}
+	return nil
+}
+
+// hopConfig returns a config for user that negotiates with the algorithm lists and
+// timeout of cfg, so jump hops honour the profile's algorithm settings too
+func hopConfig(cfg *ssh.ClientConfig, user string) *ssh.ClientConfig {
+	return &ssh.ClientConfig{Config: cfg.Config, HostKeyAlgorithms: cfg.HostKeyAlgorithms, User: user, Timeout: cfg.Timeout}
+}
+
+// explainNegotiation turns an algorithm negotiation failure into an error that
+// lists what the server offered, pointing at the legacy preset
+func explainNegotiation(err error) error {
+	var ne *ssh.AlgorithmNegotiationError
+	if !errors.As(err, &ne) {
+		return err
+	}
+	return fmt.Errorf("算法协商失败 (%s)：没有双方都支持的算法。\n服务器提供: %s\n客户端提供: %s\n\n连接老旧设备时，可在高级设置中选择“旧设备兼容”预设或手动指定算法。\n\n原始错误: %w",
+		ne.What, strings.Join(ne.RequestedAlgorithms, ", "), strings.Join(ne.SupportedAlgorithms, ", "), err)
+}
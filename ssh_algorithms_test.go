@@ -0,0 +1,57 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestApplyAlgorithms(t *testing.T) {
+	insecure := ssh.InsecureAlgorithms()
+	tests := []struct {
+		name    string
+		p       SSHParams
+		check   func(*ssh.ClientConfig) bool
+		wantErr bool
+	}{
+		{"defaults", SSHParams{}, func(c *ssh.ClientConfig) bool {
+			return c.KeyExchanges == nil && c.Ciphers == nil && c.MACs == nil && c.HostKeyAlgorithms == nil
+		}, false},
+		{"legacy preset", SSHParams{AlgorithmPreset: algoPresetLegacy}, func(c *ssh.ClientConfig) bool {
+			return slices.Contains(c.KeyExchanges, insecure.KeyExchanges[0]) && slices.Contains(c.Ciphers, ssh.InsecureCipherAES128CBC) &&
+				slices.Contains(c.HostKeyAlgorithms, ssh.KeyAlgoRSA)
+		}, false},
+		{"explicit lists win over preset", SSHParams{AlgorithmPreset: algoPresetLegacy, Ciphers: []string{ssh.InsecureCipherAES128CBC}}, func(c *ssh.ClientConfig) bool {
+			return slices.Equal(c.Ciphers, []string{ssh.InsecureCipherAES128CBC}) && len(c.MACs) > 1
+		}, false},
+		{"insecure name without preset", SSHParams{KeyExchanges: []string{ssh.InsecureKeyExchangeDH1SHA1}}, func(c *ssh.ClientConfig) bool {
+			return slices.Equal(c.KeyExchanges, []string{ssh.InsecureKeyExchangeDH1SHA1})
+		}, false},
+		{"unknown preset", SSHParams{AlgorithmPreset: "ancient"}, nil, true},
+		{"unknown cipher", SSHParams{Ciphers: []string{"rot13"}}, nil, true},
+	}
+	for _, tt := range tests {
+		cfg := &ssh.ClientConfig{}
+		err := applyAlgorithms(cfg, tt.p)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && !tt.check(cfg) {
+			t.Errorf("%s: unexpected config %+v", tt.name, cfg)
+		}
+	}
+}
+
+func TestHopConfigKeepsAlgorithms(t *testing.T) {
+	cfg := &ssh.ClientConfig{User: "target"}
+	if err := applyAlgorithms(cfg, SSHParams{AlgorithmPreset: algoPresetLegacy, MACs: []string{ssh.HMACSHA1}}); err != nil {
+		t.Fatal(err)
+	}
+	hop := hopConfig(cfg, "jump")
+	if hop.User != "jump" || !slices.Equal(hop.KeyExchanges, cfg.KeyExchanges) || !slices.Equal(hop.MACs, cfg.MACs) ||
+		!slices.Equal(hop.HostKeyAlgorithms, cfg.HostKeyAlgorithms) {
+		t.Errorf("hop config %+v does not negotiate like %+v", hop, cfg)
+	}
+}
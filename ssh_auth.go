@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/sys/windows"
+)
+
+// authMethods builds the ssh.AuthMethod list for a hop. Keyboard-interactive is always
+// offered last so OTP/2FA challenges reach the frontend. The returned release func
+// must be called once the handshake is over; it closes the ssh-agent connection if any.
+func (tm *TermManager) authMethods(a AuthInfo, host, user string) ([]ssh.AuthMethod, func(), error) {
+	return tm.tracedAuthMethods(a, host, user, func(string) {})
+}
+
+// tracedAuthMethods is authMethods reporting each method to trace when the client tries it
+func (tm *TermManager) tracedAuthMethods(a AuthInfo, host, user string, trace func(method string)) ([]ssh.AuthMethod, func(), error) {
+	var methods []ssh.AuthMethod
+	release := func() {}
+	switch a.Type {
+	case "password", "":
+		methods = append(methods, ssh.PasswordCallback(func() (string, error) {
+			trace("password")
+			return a.Password, nil
+		}))
+	case "key":
+		signer, err := parseSigner(a.KeyPEM, a.Passphrase)
+		if err != nil {
+			return nil, nil, err
+		}
+		signers := []ssh.Signer{signer}
+		if a.CertPub != "" {
+			cs, err := certSigner(a.CertPub, signer)
+			if err != nil {
+				return nil, nil, err
+			}
+			// the certificate is tried first, the bare key stays as a fallback
+			signers = []ssh.Signer{cs, signer}
+		}
+		methods = append(methods, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+			trace("publickey")
+			return signers, nil
+		}))
+	case "agent":
+		conn, err := dialAgent()
+		if err != nil {
+			return nil, nil, err
+		}
+		ag := agent.NewClient(conn)
+		methods = append(methods, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+			trace("publickey")
+			return ag.Signers()
+		}))
+		release = func() { _ = conn.Close() }
+	default:
+		return nil, nil, fmt.Errorf("unsupported auth type: %s", a.Type)
+	}
+	methods = append(methods, tm.keyboardInteractive(host, user, trace))
+	return methods, release, nil
+}
+
+func parseSigner(keyPEM, passphrase string) (ssh.Signer, error) {
+	if keyPEM == "" {
+		return nil, errors.New("key auth requires KeyPEM")
+	}
+	var signer ssh.Signer
+	var err error
+	if passphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(keyPEM), []byte(passphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey([]byte(keyPEM))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse key: %w", err)
+	}
+	return signer, nil
+}
+
+// agentPipe is where the Windows OpenSSH agent service (and Pageant, when told to
+// emulate it) listens; neither sets SSH_AUTH_SOCK
+const agentPipe = `\\.\pipe\openssh-ssh-agent`
+
+// dialAgent connects to the running ssh-agent: the unix socket named by SSH_AUTH_SOCK,
+// or else the named pipe it names or agentPipe. Pipe handles are synchronous, so
+// callers must not read and write the connection concurrently.
+func dialAgent() (io.ReadWriteCloser, error) {
+	sock := os.Getenv("SSH_AUTH_SOCK")
+	pipe := agentPipe
+	switch {
+	case strings.HasPrefix(sock, `\\.\pipe\`):
+		pipe = sock
+	case sock != "":
+		conn, err := net.Dial("unix", sock)
+		if err == nil {
+			return conn, nil
+		}
+		log.Printf("[Agent] SSH_AUTH_SOCK %s is not usable, trying %s: %v", sock, agentPipe, err)
+	}
+	name, err := windows.UTF16PtrFromString(pipe)
+	if err != nil {
+		return nil, err
+	}
+	h, err := windows.CreateFile(name, windows.GENERIC_READ|windows.GENERIC_WRITE, 0, nil, windows.OPEN_EXISTING, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("connect ssh-agent %s: %w", pipe, err)
+	}
+	return os.NewFile(uintptr(h), pipe), nil
+}
+
+// agentAvailable reports whether dialAgent would find a running agent
+func agentAvailable() bool {
+	conn, err := dialAgent()
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
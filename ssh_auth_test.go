@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func newTestSigner(t *testing.T) (ssh.Signer, ed25519.PrivateKey) {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, priv
+}
+
+// acceptKey is a server PublicKeyCallback that accepts only want
+func acceptKey(want ssh.PublicKey) func(ssh.ConnMetadata, ssh.PublicKey) (*ssh.Permissions, error) {
+	return func(_ ssh.ConnMetadata, k ssh.PublicKey) (*ssh.Permissions, error) {
+		if !bytes.Equal(k.Marshal(), want.Marshal()) {
+			return nil, errors.New("unknown key")
+		}
+		return nil, nil
+	}
+}
+
+// handshake authenticates methods against an in-process server configured by scfg
+func handshake(t *testing.T, methods []ssh.AuthMethod, scfg *ssh.ServerConfig) error {
+	t.Helper()
+	hostKey, _ := newTestSigner(t)
+	scfg.AddHostKey(hostKey)
+	// both ends send their version line first, so net.Pipe would deadlock
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		s, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer s.Close()
+		if conn, _, _, err := ssh.NewServerConn(s, scfg); err == nil {
+			conn.Close()
+		}
+	}()
+	c, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	conn, _, _, err := ssh.NewClientConn(c, "h:22", &ssh.ClientConfig{User: "ops", Auth: methods, HostKeyCallback: ssh.InsecureIgnoreHostKey()})
+	if err == nil {
+		conn.Close()
+	}
+	return err
+}
+
+// serveTestAgent runs an agent holding keys on a unix socket named by SSH_AUTH_SOCK
+func serveTestAgent(t *testing.T, keys ...ed25519.PrivateKey) {
+	t.Helper()
+	ring := agent.NewKeyring()
+	for _, k := range keys {
+		if err := ring.Add(agent.AddedKey{PrivateKey: k}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sock := filepath.Join(t.TempDir(), "agent.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				_ = agent.ServeAgent(ring, c)
+			}()
+		}
+	}()
+	t.Setenv("SSH_AUTH_SOCK", sock)
+}
+
+func TestAgentAuth(t *testing.T) {
+	tm := NewTermManager()
+	key, priv := newTestSigner(t)
+	other, _ := newTestSigner(t)
+	serveTestAgent(t, priv)
+
+	methods, release, err := tm.authMethods(AuthInfo{Type: "agent"}, "h", "u")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	if err := handshake(t, methods, &ssh.ServerConfig{PublicKeyCallback: acceptKey(key.PublicKey())}); err != nil {
+		t.Errorf("agent key rejected: %v", err)
+	}
+	if err := handshake(t, methods, &ssh.ServerConfig{PublicKeyCallback: acceptKey(other.PublicKey())}); err == nil {
+		t.Error("authenticated with a key the agent does not hold")
+	}
+}
+
+func TestAgentUnavailable(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", `\\.\pipe\xgoterm-test-no-agent`)
+	if _, _, err := NewTermManager().authMethods(AuthInfo{Type: "agent"}, "h", "u"); err == nil || !strings.Contains(err.Error(), "connect ssh-agent") {
+		t.Errorf("err = %v, want a connect ssh-agent error", err)
+	}
+	if agentAvailable() {
+		t.Error("agentAvailable with no agent running")
+	}
+}
+
+func TestAuthMethodsErrors(t *testing.T) {
+	tm := NewTermManager()
+	for _, a := range []AuthInfo{{Type: "key"}, {Type: "key", KeyPEM: "not a key"}, {Type: "gssapi"}} {
+		if _, _, err := tm.authMethods(a, "h", "u"); err == nil {
+			t.Errorf("authMethods(%+v) succeeded", a)
+		}
+	}
+}
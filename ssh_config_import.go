@@ -0,0 +1,462 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// SSHConfigEntry is one host that would be created from an OpenSSH config file
+type SSHConfigEntry struct {
+	Alias    string      `json:"alias"`
+	Profile  HostProfile `json:"profile"`
+	Exists   bool        `json:"exists"` // an identical host/port/user profile is already saved
+	Warnings []string    `json:"warnings,omitempty"`
+}
+
+// PreviewSSHConfig parses an OpenSSH client config (default ~/.ssh/config) and returns
+// the profiles ImportSSHConfig would create, without saving anything.
+func (pm *ProfilesManager) PreviewSSHConfig(path string) ([]SSHConfigEntry, error) {
+	if path == "" {
+		path = filepath.Join(homeDir(), ".ssh", "config")
+	}
+	cfg, err := parseSSHConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := pm.ListProfiles()
+	if err != nil {
+		return nil, err
+	}
+	var out []SSHConfigEntry
+	for _, alias := range cfg.aliases() {
+		e := cfg.entry(alias)
+		for _, h := range existing {
+			if strings.EqualFold(h.Host, e.Profile.Host) && h.Port == e.Profile.Port && h.Username == e.Profile.Username {
+				e.Exists = true
+				break
+			}
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+// ImportSSHConfig saves the previewed hosts named in aliases (all when empty),
+// skipping ones that already exist. Returns the number of profiles created.
+func (pm *ProfilesManager) ImportSSHConfig(path string, aliases []string) (int, error) {
+	entries, err := pm.PreviewSSHConfig(path)
+	if err != nil {
+		return 0, err
+	}
+	want := map[string]bool{}
+	for _, a := range aliases {
+		want[a] = true
+	}
+	added := 0
+	for _, e := range entries {
+		if e.Exists || (len(want) > 0 && !want[e.Alias]) {
+			continue
+		}
+		if _, err := pm.SaveProfile(e.Profile); err != nil {
+			return added, fmt.Errorf("import %s: %w", e.Alias, err)
+		}
+		added++
+	}
+	return added, nil
+}
+
+// sshConfig is a parsed OpenSSH client config: Host blocks in file order, Includes inlined
+type sshConfig struct {
+	blocks []*sshConfigBlock
+}
+
+type sshConfigBlock struct {
+	patterns []string // Host patterns; nil for Match blocks, which are never applied
+	opts     [][2]string
+}
+
+func parseSSHConfig(path string) (*sshConfig, error) {
+	cfg := &sshConfig{}
+	global := &sshConfigBlock{patterns: []string{"*"}}
+	cfg.blocks = append(cfg.blocks, global)
+	cur := global
+	if err := cfg.parseFile(path, &cur, 0); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *sshConfig) parseFile(path string, cur **sshConfigBlock, depth int) error {
+	if depth > 16 {
+		return errors.New("ssh config: Include nested too deeply")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		key, args := splitSSHConfigLine(sc.Text())
+		if key == "" {
+			continue
+		}
+		switch key {
+		case "host":
+			*cur = &sshConfigBlock{patterns: args}
+			c.blocks = append(c.blocks, *cur)
+		case "match":
+			*cur = &sshConfigBlock{}
+			c.blocks = append(c.blocks, *cur)
+		case "include":
+			outer := *cur
+			for _, pattern := range args {
+				pattern = expandTilde(pattern)
+				if !filepath.IsAbs(pattern) {
+					pattern = filepath.Join(homeDir(), ".ssh", pattern)
+				}
+				matches, _ := filepath.Glob(pattern)
+				for _, m := range matches {
+					if err := c.parseFile(m, cur, depth+1); err != nil {
+						return fmt.Errorf("include %s: %w", m, err)
+					}
+				}
+			}
+			// lines after the Include belong to the block that contained it
+			if *cur != outer {
+				*cur = &sshConfigBlock{patterns: outer.patterns}
+				c.blocks = append(c.blocks, *cur)
+			}
+		default:
+			if len(args) > 0 {
+				(*cur).opts = append((*cur).opts, [2]string{key, strings.Join(args, " ")})
+			}
+		}
+	}
+	return sc.Err()
+}
+
+// splitSSHConfigLine returns the lower-cased keyword and its arguments
+func splitSSHConfigLine(line string) (string, []string) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", nil
+	}
+	var fields []string
+	var b strings.Builder
+	quoted, have, eq := false, false, false
+	for _, r := range line {
+		// one '=' may separate the keyword from its arguments, with or without blanks
+		sep := r == '=' && !eq && (len(fields) == 0 || len(fields) == 1 && !have)
+		switch {
+		case r == '"':
+			quoted = !quoted
+			have = true
+		case !quoted && (r == ' ' || r == '\t' || sep):
+			eq = eq || sep
+			if have {
+				fields = append(fields, b.String())
+				b.Reset()
+				have = false
+			}
+		default:
+			b.WriteRune(r)
+			have = true
+		}
+	}
+	if have {
+		fields = append(fields, b.String())
+	}
+	if len(fields) == 0 {
+		return "", nil
+	}
+	return strings.ToLower(fields[0]), fields[1:]
+}
+
+// aliases lists the concrete (wildcard-free, non-negated) Host names in file order
+func (c *sshConfig) aliases() []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, b := range c.blocks {
+		for _, p := range b.patterns {
+			if strings.ContainsAny(p, "*?!") || seen[p] {
+				continue
+			}
+			seen[p] = true
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// lookup returns every value of key that applies to alias, first one winning as in OpenSSH
+func (c *sshConfig) lookup(alias, key string) []string {
+	var vals []string
+	for _, b := range c.blocks {
+		if !matchHostPatterns(alias, b.patterns) {
+			continue
+		}
+		for _, o := range b.opts {
+			if o[0] == key {
+				vals = append(vals, o[1])
+			}
+		}
+	}
+	return vals
+}
+
+func (c *sshConfig) get(alias, key string) string {
+	if v := c.lookup(alias, key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func (c *sshConfig) entry(alias string) SSHConfigEntry {
+	e := SSHConfigEntry{Alias: alias}
+	host, port, username := c.endpoint(alias)
+	p := HostProfile{
+		Name:     alias,
+		Host:     host,
+		Port:     port,
+		Username: username,
+		Tags:     []string{"ssh_config"},
+	}
+	p.Auth, e.Warnings = c.auth(alias, host, username)
+	if v, err := strconv.Atoi(c.get(alias, "serveraliveinterval")); err == nil && v > 0 {
+		p.KeepAliveSec = v
+	}
+	if v, err := strconv.Atoi(c.get(alias, "connecttimeout")); err == nil && v > 0 {
+		p.TimeoutSec = v
+	}
+	for _, kv := range strings.Fields(c.get(alias, "setenv")) {
+		if k, v, ok := strings.Cut(kv, "="); ok && k != "" {
+			if p.Env == nil {
+				p.Env = make(map[string]string)
+			}
+			p.Env[k] = v
+		}
+	}
+	jumps, warns := c.jumps(alias, 0)
+	p.Jumps = jumps
+	e.Warnings = append(e.Warnings, warns...)
+	if c.get(alias, "proxycommand") != "" && len(jumps) == 0 {
+		e.Warnings = append(e.Warnings, "不支持 ProxyCommand，已忽略")
+	}
+	e.Profile = p
+	return e
+}
+
+// endpoint resolves HostName/Port/User for alias
+func (c *sshConfig) endpoint(alias string) (string, int, string) {
+	host := alias
+	if hn := c.get(alias, "hostname"); hn != "" {
+		host = strings.ReplaceAll(hn, "%h", alias)
+	}
+	port := 22
+	if v, err := strconv.Atoi(c.get(alias, "port")); err == nil && v > 0 {
+		port = v
+	}
+	username := c.get(alias, "user")
+	if username == "" {
+		username = localUsername()
+	}
+	return host, port, username
+}
+
+// auth loads the first IdentityFile into KeyPEM, falling back to ssh-agent, the default
+// identities and finally an empty password the user has to fill in.
+func (c *sshConfig) auth(alias, host, username string) (AuthInfo, []string) {
+	var warns []string
+	tokens := strings.NewReplacer("%d", homeDir(), "%u", localUsername(), "%h", host, "%r", username, "%%", "%")
+	for _, idf := range c.lookup(alias, "identityfile") {
+		path := expandTilde(tokens.Replace(idf))
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(homeDir(), ".ssh", path)
+		}
+		a, warn, err := loadIdentity(path)
+		if err != nil {
+			warns = append(warns, fmt.Sprintf("无法读取 IdentityFile %s: %v", path, err))
+			continue
+		}
+		if warn != "" {
+			warns = append(warns, warn)
+		}
+		if cf := c.get(alias, "certificatefile"); cf != "" {
+			cf = expandTilde(tokens.Replace(cf))
+			if !filepath.IsAbs(cf) {
+				cf = filepath.Join(homeDir(), ".ssh", cf)
+			}
+			if b, err := os.ReadFile(cf); err == nil {
+				a.CertPub = string(b)
+			} else {
+				warns = append(warns, fmt.Sprintf("无法读取 CertificateFile %s: %v", cf, err))
+			}
+		}
+		return a, warns
+	}
+	if !strings.EqualFold(c.get(alias, "identitiesonly"), "yes") && agentAvailable() {
+		return AuthInfo{Type: "agent"}, warns
+	}
+	for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+		if a, warn, err := loadIdentity(filepath.Join(homeDir(), ".ssh", name)); err == nil {
+			if warn != "" {
+				warns = append(warns, warn)
+			}
+			return a, warns
+		}
+	}
+	warns = append(warns, "未找到可用私钥，请在导入后填写密码")
+	return AuthInfo{Type: "password"}, warns
+}
+
+func loadIdentity(path string) (AuthInfo, string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return AuthInfo{}, "", err
+	}
+	warn := ""
+	if _, err := ssh.ParseRawPrivateKey(b); err != nil {
+		var missing *ssh.PassphraseMissingError
+		if !errors.As(err, &missing) {
+			return AuthInfo{}, "", err
+		}
+		warn = fmt.Sprintf("私钥 %s 有密码保护，请在导入后填写私钥密码", path)
+	}
+	a := AuthInfo{Type: "key", KeyPEM: string(b)}
+	// like ssh(1), pick up the matching certificate next to the key
+	if cert, err := os.ReadFile(path + "-cert.pub"); err == nil {
+		a.CertPub = string(cert)
+	}
+	return a, warn, nil
+}
+
+// jumps maps ProxyJump onto the hop list, resolving hops that are aliases themselves
+func (c *sshConfig) jumps(alias string, depth int) ([]JumpHost, []string) {
+	spec := c.get(alias, "proxyjump")
+	if spec == "" || strings.EqualFold(spec, "none") {
+		return nil, nil
+	}
+	if depth > 8 {
+		return nil, []string{"ProxyJump 嵌套过深，已截断"}
+	}
+	var out []JumpHost
+	var warns []string
+	for _, hop := range strings.Split(spec, ",") {
+		hop = strings.TrimPrefix(strings.TrimSpace(hop), "ssh://")
+		hopUser := ""
+		if i := strings.LastIndex(hop, "@"); i >= 0 {
+			hopUser, hop = hop[:i], hop[i+1:]
+		}
+		hopName, hopPort := hop, 0
+		if h, p, err := splitHostPortLoose(hop); err == nil {
+			hopName, hopPort = h, p
+		}
+		inner, w := c.jumps(hopName, depth+1)
+		out = append(out, inner...)
+		warns = append(warns, w...)
+
+		host, port, username := c.endpoint(hopName)
+		if hopPort > 0 {
+			port = hopPort
+		}
+		if hopUser != "" {
+			username = hopUser
+		}
+		a, w := c.auth(hopName, host, username)
+		for _, s := range w {
+			warns = append(warns, "跳板机 "+hopName+": "+s)
+		}
+		out = append(out, JumpHost{Host: host, Port: port, User: username, Auth: a})
+	}
+	return out, warns
+}
+
+func splitHostPortLoose(s string) (string, int, error) {
+	i := strings.LastIndex(s, ":")
+	if i < 0 || strings.Count(s, ":") > 1 && !strings.HasPrefix(s, "[") {
+		return "", 0, errors.New("no port")
+	}
+	port, err := strconv.Atoi(s[i+1:])
+	if err != nil {
+		return "", 0, err
+	}
+	return strings.Trim(s[:i], "[]"), port, nil
+}
+
+// matchHostPatterns implements ssh_config Host matching: any negated match excludes
+func matchHostPatterns(host string, patterns []string) bool {
+	matched := false
+	for _, p := range patterns {
+		neg := strings.HasPrefix(p, "!")
+		if wildcardMatch(strings.ToLower(strings.TrimPrefix(p, "!")), strings.ToLower(host)) {
+			if neg {
+				return false
+			}
+			matched = true
+		}
+	}
+	return matched
+}
+
+// wildcardMatch matches s against a pattern where * is any run and ? any single char
+func wildcardMatch(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for i := len(s); i >= 0; i-- {
+				if wildcardMatch(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+		default:
+			if len(s) == 0 || s[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern, s = pattern[1:], s[1:]
+	}
+	return len(s) == 0
+}
+
+func homeDir() string {
+	h, err := os.UserHomeDir()
+	if err != nil {
+		return "."
+	}
+	return h
+}
+
+func expandTilde(p string) string {
+	if p == "~" {
+		return homeDir()
+	}
+	if strings.HasPrefix(p, "~/") || strings.HasPrefix(p, `~\`) {
+		return filepath.Join(homeDir(), p[2:])
+	}
+	return p
+}
+
+func localUsername() string {
+	if u, err := user.Current(); err == nil {
+		name := u.Username
+		if i := strings.LastIndex(name, `\`); i >= 0 {
+			name = name[i+1:] // strip the DOMAIN\ prefix on Windows
+		}
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitSSHConfigLine(t *testing.T) {
+	tests := []struct {
+		line string
+		key  string
+		args []string
+	}{
+		{"", "", nil},
+		{"   # comment", "", nil},
+		{"Host web-*", "host", []string{"web-*"}},
+		{"  HostName\t10.0.0.5  ", "hostname", []string{"10.0.0.5"}},
+		{"Port=2222", "port", []string{"2222"}},
+		{"Port = 2222", "port", []string{"2222"}},
+		{"Port =2222", "port", []string{"2222"}},
+		{"Host a b !c", "host", []string{"a", "b", "!c"}},
+		{`IdentityFile "C:\Users\me\.ssh\id key"`, "identityfile", []string{`C:\Users\me\.ssh\id key`}},
+		{`ProxyCommand ssh -W %h:%p "jump host"`, "proxycommand", []string{"ssh", "-W", "%h:%p", "jump host"}},
+		{"SetEnv FOO=bar", "setenv", []string{"FOO=bar"}},
+		{`User ""`, "user", []string{""}},
+	}
+	for _, tt := range tests {
+		key, args := splitSSHConfigLine(tt.line)
+		if key != tt.key || !slices.Equal(args, tt.args) {
+			t.Errorf("splitSSHConfigLine(%q) = %q %q, want %q %q", tt.line, key, args, tt.key, tt.args)
+		}
+	}
+}
+
+func TestWildcardMatch(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"*", "", true},
+		{"*", "anything", true},
+		{"web", "web", true},
+		{"web", "web1", false},
+		{"web-*", "web-01", true},
+		{"web-*", "db-01", false},
+		{"*.example.com", "a.b.example.com", true},
+		{"*.example.com", "example.com", false},
+		{"10.0.?.*", "10.0.1.20", true},
+		{"10.0.?.*", "10.0.12.20", false},
+		{"?", "", false},
+		{"a*b*c", "aXXbYYc", true},
+		{"a*b*c", "aXXbYY", false},
+	}
+	for _, tt := range tests {
+		if got := wildcardMatch(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("wildcardMatch(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestMatchHostPatterns(t *testing.T) {
+	tests := []struct {
+		host     string
+		patterns []string
+		want     bool
+	}{
+		{"web1", []string{"web*"}, true},
+		{"WEB1", []string{"web*"}, true},
+		{"web-test", []string{"web*", "!*-test"}, false},
+		{"web-test", []string{"!*-test", "web*"}, false},
+		{"db", []string{"!web*"}, false},
+		{"db", []string{"web*", "db"}, true},
+	}
+	for _, tt := range tests {
+		if got := matchHostPatterns(tt.host, tt.patterns); got != tt.want {
+			t.Errorf("matchHostPatterns(%q, %q) = %v, want %v", tt.host, tt.patterns, got, tt.want)
+		}
+	}
+}
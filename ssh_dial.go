@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// JumpHost is one ProxyJump hop. Hops are dialed in order, each through the previous one.
+type JumpHost struct {
+	Host string   `json:"host"`
+	Port int      `json:"port,omitempty"`
+	User string   `json:"user"`
+	Auth AuthInfo `json:"auth"`
+}
+
+func (j JumpHost) addr() string {
+	port := j.Port
+	if port == 0 {
+		port = 22
+	}
+	return net.JoinHostPort(j.Host, strconv.Itoa(port))
+}
+
+// dialSSH authenticates to p's target, through its ProxyJump chain if any
+func (tm *TermManager) dialSSH(p SSHParams) (*ssh.Client, []*ssh.Client, error) {
+	addr := net.JoinHostPort(p.Host, strconv.Itoa(p.Port))
+	authMethods, releaseAuth, err := tm.authMethods(AuthInfo{Type: p.AuthType, Password: p.Password, KeyPEM: p.KeyPEM, Passphrase: p.Passphrase, CertPub: p.CertPub}, p.Host, p.Username)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer releaseAuth()
+
+	cfg := &ssh.ClientConfig{
+		User:            p.Username,
+		Auth:            authMethods,
+		HostKeyCallback: tm.hostKeyCallback(),
+		Timeout: func() time.Duration {
+			if p.TimeoutSec > 0 {
+				return time.Duration(p.TimeoutSec) * time.Second
+			}
+			return 10 * time.Second
+		}(),
+	}
+	if err := applyAlgorithms(cfg, p); err != nil {
+		return nil, nil, err
+	}
+	return tm.dialChain(p.Jumps, addr, cfg, tm.effectiveProxy(p.Proxy))
+}
+
+// dialChain connects to addr through jumps in order; proxy carries the first TCP hop.
+// On success the caller owns the returned target client and every intermediate jump
+// client (see closeChain).
+func (tm *TermManager) dialChain(jumps []JumpHost, addr string, cfg *ssh.ClientConfig, proxy ProxyConfig) (*ssh.Client, []*ssh.Client, error) {
+	var chain []*ssh.Client
+	var via *ssh.Client
+	for i, j := range jumps {
+		if j.Host == "" || j.User == "" {
+			closeChain(chain)
+			return nil, nil, fmt.Errorf("跳板机 #%d 缺少地址或用户名", i+1)
+		}
+		jAddr := j.addr()
+		log.Printf("[ProxyJump] 正在连接跳板机 #%d: %s@%s", i+1, j.User, jAddr)
+		methods, release, err := tm.authMethods(j.Auth, j.Host, j.User)
+		if err != nil {
+			closeChain(chain)
+			return nil, nil, fmt.Errorf("跳板机 #%d 认证配置错误: %w", i+1, err)
+		}
+		jCfg := hopConfig(cfg, j.User)
+		jCfg.Auth, jCfg.HostKeyCallback = methods, tm.hostKeyCallback()
+		cli, err := dialHop(via, jAddr, jCfg, proxy)
+		release()
+		if err != nil {
+			closeChain(chain)
+			return nil, nil, fmt.Errorf("连接跳板机 #%d 失败 (%s@%s): %w", i+1, j.User, jAddr, err)
+		}
+		log.Printf("[ProxyJump] ✓ 跳板机 #%d 连接成功", i+1)
+		chain = append(chain, cli)
+		via = cli
+	}
+
+	if via != nil {
+		log.Printf("[ProxyJump] 通过跳板机连接目标主机: %s@%s", cfg.User, addr)
+	}
+	client, err := dialHop(via, addr, cfg, proxy)
+	if err != nil {
+		closeChain(chain)
+		if via != nil {
+			return nil, nil, fmt.Errorf("目标主机连接失败 (%s@%s): %w", cfg.User, addr, err)
+		}
+		return nil, nil, err
+	}
+	if via != nil {
+		log.Printf("[ProxyJump] ✓ 目标主机连接成功")
+	}
+	return client, chain, nil
+}
+
+// dialHop opens an SSH connection to addr, through an existing client or, for the
+// first hop, over TCP (via proxy when enabled)
+func dialHop(via *ssh.Client, addr string, cfg *ssh.ClientConfig, proxy ProxyConfig) (*ssh.Client, error) {
+	cfg = withKnownHostKeyAlgorithms(cfg, addr)
+	var conn net.Conn
+	var err error
+	if via == nil {
+		if proxy.enabled() {
+			log.Printf("[Proxy] dialing %s via %s proxy %s", addr, proxy.Type, proxy.addr())
+		}
+		if conn, err = dialTCP(proxy, addr, cfg.Timeout); err != nil {
+			return nil, err
+		}
+	} else if conn, err = via.Dial("tcp", addr); err != nil {
+		if !isForwardingProhibited(err) {
+			return nil, err
+		}
+		log.Printf("[ProxyJump] 检测到端口转发被禁止，改用 stdio 隧道: %v", err)
+		cli, serr := dialStdio(via, addr, cfg)
+		if serr != nil {
+			return nil, fmt.Errorf("跳板机禁止了端口转发功能，且无法通过 nc/ncat 建立 stdio 隧道。\n\n原始错误: %w\n%v", err, serr)
+		}
+		return cli, nil
+	}
+	cconn, chans, reqs, err := ssh.NewClientConn(conn, addr, cfg)
+	if err != nil {
+		_ = conn.Close()
+		return nil, explainNegotiation(err)
+	}
+	return ssh.NewClient(cconn, chans, reqs), nil
+}
+
+// closeChain closes jump clients from the innermost hop outwards
+func closeChain(chain []*ssh.Client) {
+	for i := len(chain) - 1; i >= 0; i-- {
+		_ = chain[i].Close()
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// stdioTunnelCommands are tried in order on a gateway that refuses direct-tcpip
+// channels (AllowTcpForwarding no); each one relays its stdin/stdout to host:port.
+// "ssh -W" is deliberately absent: it would log in to the target a second time
+// with the gateway user's keys instead of relaying plain TCP.
+var stdioTunnelCommands = []func(host, port string) string{
+	func(host, port string) string { return fmt.Sprintf("nc %s %s", shellQuote(host), shellQuote(port)) },
+	func(host, port string) string { return fmt.Sprintf("ncat %s %s", shellQuote(host), shellQuote(port)) },
+}
+
+// isForwardingProhibited reports whether the gateway refused a direct-tcpip channel
+// by policy, as opposed to the target being down or refusing the connection
+func isForwardingProhibited(err error) bool {
+	var oce *ssh.OpenChannelError
+	return errors.As(err, &oce) && oce.Reason == ssh.Prohibited
+}
+
+// dialStdio performs the SSH handshake with addr over an exec channel on via,
+// trying each of stdioTunnelCommands until one carries the SSH stream.
+func dialStdio(via *ssh.Client, addr string, cfg *ssh.ClientConfig) (*ssh.Client, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	var errs []string
+	for _, mk := range stdioTunnelCommands {
+		cmd := mk(host, port)
+		conn, err := openStdioConn(via, cmd, addr)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", cmd, err))
+			continue
+		}
+		log.Printf("[ProxyJump] 端口转发被禁止，尝试 stdio 隧道: %s", cmd)
+		cconn, chans, reqs, err := stdioHandshake(conn, addr, cfg)
+		if err == nil {
+			log.Printf("[ProxyJump] ✓ stdio 隧道已建立: %s", cmd)
+			return ssh.NewClient(cconn, chans, reqs), nil
+		}
+		_ = conn.Close()
+		if conn.established() {
+			// the tunnel carried SSH traffic, so the failure is the target's (auth, host key...)
+			return nil, explainNegotiation(err)
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v%s", cmd, err, conn.stderrSuffix()))
+	}
+	return nil, fmt.Errorf("stdio tunnel failed:\n  %s", strings.Join(errs, "\n  "))
+}
+
+// stdioHandshake runs the SSH handshake over conn within cfg.Timeout. stdioConn has
+// no deadlines, so a hung tunnel is cut off by closing its session instead.
+func stdioHandshake(conn *stdioConn, addr string, cfg *ssh.ClientConfig) (ssh.Conn, <-chan ssh.NewChannel, <-chan *ssh.Request, error) {
+	if cfg.Timeout <= 0 {
+		return ssh.NewClientConn(conn, addr, cfg)
+	}
+	var timedOut atomic.Bool
+	t := time.AfterFunc(cfg.Timeout, func() {
+		timedOut.Store(true)
+		_ = conn.Close()
+	})
+	cconn, chans, reqs, err := ssh.NewClientConn(conn, addr, cfg)
+	if !t.Stop() && timedOut.Load() {
+		if err == nil {
+			_ = cconn.Close()
+		}
+		return nil, nil, nil, fmt.Errorf("ssh handshake over stdio tunnel timed out after %s", cfg.Timeout)
+	}
+	return cconn, chans, reqs, err
+}
+
+// stdioConn adapts an exec channel's stdin/stdout to net.Conn
+type stdioConn struct {
+	sess   *ssh.Session
+	stdin  io.WriteCloser
+	stdout io.Reader
+	raddr  stdioAddr
+
+	mu     sync.Mutex
+	got    bool
+	stderr bytes.Buffer
+}
+
+func openStdioConn(via *ssh.Client, cmd string, addr string) (*stdioConn, error) {
+	s, err := via.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	c := &stdioConn{sess: s, raddr: stdioAddr(addr)}
+	if c.stdin, err = s.StdinPipe(); err != nil {
+		_ = s.Close()
+		return nil, err
+	}
+	if c.stdout, err = s.StdoutPipe(); err != nil {
+		_ = s.Close()
+		return nil, err
+	}
+	s.Stderr = &limitedWriter{c: c}
+	if err := s.Start(cmd); err != nil {
+		_ = s.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *stdioConn) Read(p []byte) (int, error) {
+	n, err := c.stdout.Read(p)
+	if n > 0 {
+		c.mu.Lock()
+		c.got = true
+		c.mu.Unlock()
+	}
+	return n, err
+}
+
+func (c *stdioConn) Write(p []byte) (int, error) { return c.stdin.Write(p) }
+
+func (c *stdioConn) Close() error {
+	_ = c.stdin.Close()
+	return c.sess.Close()
+}
+
+func (c *stdioConn) LocalAddr() net.Addr                { return stdioAddr("stdio") }
+func (c *stdioConn) RemoteAddr() net.Addr               { return c.raddr }
+func (c *stdioConn) SetDeadline(t time.Time) error      { return nil }
+func (c *stdioConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *stdioConn) SetWriteDeadline(t time.Time) error { return nil }
+
+// established reports whether the remote command ever produced output
+func (c *stdioConn) established() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.got
+}
+
+func (c *stdioConn) stderrSuffix() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s := strings.TrimSpace(c.stderr.String()); s != "" {
+		return " (" + s + ")"
+	}
+	return ""
+}
+
+// limitedWriter keeps the first few hundred bytes of the tunnel command's stderr
+type limitedWriter struct{ c *stdioConn }
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	w.c.mu.Lock()
+	defer w.c.mu.Unlock()
+	if room := 512 - w.c.stderr.Len(); room > 0 {
+		if len(p) > room {
+			w.c.stderr.Write(p[:room])
+		} else {
+			w.c.stderr.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+type stdioAddr string
+
+func (a stdioAddr) Network() string { return "stdio" }
+func (a stdioAddr) String() string  { return string(a) }
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestIsForwardingProhibited(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"prohibited", &ssh.OpenChannelError{Reason: ssh.Prohibited, Message: "open failed"}, true},
+		{"wrapped", fmt.Errorf("dial: %w", &ssh.OpenChannelError{Reason: ssh.Prohibited}), true},
+		{"connection refused", &ssh.OpenChannelError{Reason: ssh.ConnectionFailed, Message: "open failed"}, false},
+		{"unknown channel type", &ssh.OpenChannelError{Reason: ssh.UnknownChannelType}, false},
+		{"plain error mentioning it", errors.New("ssh: rejected: administratively prohibited (open failed)"), false},
+	}
+	for _, tt := range tests {
+		if got := isForwardingProhibited(tt.err); got != tt.want {
+			t.Errorf("%s: isForwardingProhibited = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"host":        `'host'`,
+		"":            `''`,
+		"a b":         `'a b'`,
+		"it's":        `'it'\''s'`,
+		"$(rm -rf /)": `'$(rm -rf /)'`,
+		"10.0.0.1:22": `'10.0.0.1:22'`,
+	}
+	for in, want := range tests {
+		if got := shellQuote(in); got != want {
+			t.Errorf("shellQuote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"io"
+	"log"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// how long to wait for PromptPattern before giving up on the remaining commands
+	startupPromptTimeout = 15 * time.Second
+	// output kept for prompt matching; prompts are always at the tail
+	startupTailSize = 4096
+)
+
+// ansiEscape matches CSI/OSC sequences and two-byte escapes (ESC =, ESC 7, ...) so
+// colored prompts can be matched as plain text
+var ansiEscape = regexp.MustCompile(`\x1b(\[[0-?]*[ -/]*[@-~]|\][^\x07\x1b]*(\x07|\x1b\\)|[0-Z\\-_])`)
+
+// setEnv sends the profile's environment before the shell starts. Servers only
+// accept variables listed in their AcceptEnv, so refusals are logged, not fatal.
+func setEnv(s *ssh.Session, env map[string]string) {
+	names := make([]string, 0, len(env))
+	for k := range env {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		if err := s.Setenv(k, env[k]); err != nil {
+			log.Printf("[Startup] server refused env %s (check AcceptEnv in sshd_config): %v", k, err)
+		}
+	}
+}
+
+// SessionStarted is the payload of term:started:<id>
+type SessionStarted struct {
+	// the backend types startup commands itself, so the frontend must not send its
+	// own "\r" to refresh the prompt
+	StartupCommands bool `json:"startupCommands"`
+}
+
+// emitStarted tells the frontend that the shell of ss is running
+func (tm *TermManager) emitStarted(ss *sshSession) {
+	runtime.EventsEmit(tm.ctx, "term:started:"+ss.id, SessionStarted{StartupCommands: len(ss.params.StartupCommands) > 0})
+}
+
+// promptWatcher collects shell output until it matches the prompt pattern
+type promptWatcher struct {
+	re      *regexp.Regexp
+	mu      sync.Mutex
+	tail    []byte
+	matched chan struct{}
+}
+
+func (w *promptWatcher) feed(p []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.tail = append(w.tail, p...)
+	if len(w.tail) > startupTailSize {
+		w.tail = w.tail[len(w.tail)-startupTailSize:]
+	}
+	if w.re.Match(ansiEscape.ReplaceAll(w.tail, nil)) {
+		w.tail = w.tail[:0]
+		select {
+		case w.matched <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// reset forgets output and matches seen so far, so the next command waits for a
+// prompt printed after the previous one was typed
+func (w *promptWatcher) reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.tail = w.tail[:0]
+	select {
+	case <-w.matched:
+	default:
+	}
+}
+
+// runStartup replaces the bare "\r" sent after the shell starts: it types the
+// profile's startup commands in order, waiting for PromptPattern before each one
+// when set. Called right after Shell(), with stdin of the new session.
+func (tm *TermManager) runStartup(ss *sshSession, stdin io.Writer) {
+	cmds := ss.params.StartupCommands
+	if len(cmds) == 0 {
+		_, _ = io.WriteString(stdin, "\r")
+		return
+	}
+	var w *promptWatcher
+	if ss.params.PromptPattern != "" {
+		re, err := regexp.Compile(ss.params.PromptPattern)
+		if err != nil {
+			// validated when the session starts; keep the shell usable regardless
+			log.Printf("[Startup] session %s: bad prompt pattern: %v", ss.id, err)
+			return
+		}
+		w = &promptWatcher{re: re, matched: make(chan struct{}, 1)}
+		ss.prompt.Store(w)
+	}
+	go func() {
+		defer ss.prompt.CompareAndSwap(w, nil)
+		for i, cmd := range cmds {
+			if w != nil {
+				select {
+				case <-w.matched:
+				case <-ss.quit:
+					return
+				case <-time.After(startupPromptTimeout):
+					log.Printf("[Startup] session %s: prompt %q not seen, skipping %d remaining command(s)", ss.id, ss.params.PromptPattern, len(cmds)-i)
+					return
+				}
+			}
+			n, err := io.WriteString(stdin, cmd+"\r")
+			ss.bytesOut.Add(int64(n))
+			if w != nil {
+				w.reset()
+			}
+			if err != nil {
+				log.Printf("[Startup] session %s: %v", ss.id, err)
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestPromptWatcher(t *testing.T) {
+	matched := func(w *promptWatcher) bool {
+		select {
+		case <-w.matched:
+			return true
+		default:
+			return false
+		}
+	}
+	w := &promptWatcher{re: regexp.MustCompile(`[$#] $`), matched: make(chan struct{}, 1)}
+
+	w.feed([]byte("Last login: today\r\n"))
+	if matched(w) {
+		t.Fatal("matched before the prompt")
+	}
+	// colored prompt split across reads
+	w.feed([]byte("\x1b[01;32mops@web\x1b[00m:~"))
+	w.feed([]byte("$ "))
+	if !matched(w) {
+		t.Fatal("colored prompt not matched")
+	}
+
+	// a prompt seen before the command was typed must not release the next one
+	w.feed([]byte("$ "))
+	w.reset()
+	if matched(w) {
+		t.Fatal("stale match survived reset")
+	}
+	w.feed([]byte("sudo -i\r\n[sudo] password for ops: "))
+	if matched(w) {
+		t.Fatal("password prompt matched the shell prompt")
+	}
+	w.feed([]byte("\r\nroot@web:~# "))
+	if !matched(w) {
+		t.Fatal("root prompt not matched")
+	}
+}
+
+func TestPromptWatcherTailLimit(t *testing.T) {
+	w := &promptWatcher{re: regexp.MustCompile(`^start`), matched: make(chan struct{}, 1)}
+	w.feed([]byte("start"))
+	<-w.matched
+	w.feed([]byte("start"))
+	w.reset()
+	// only the last startupTailSize bytes are matched, so "start" has scrolled out
+	w.feed([]byte("start" + strings.Repeat("y", startupTailSize)))
+	if len(w.tail) != startupTailSize {
+		t.Errorf("tail holds %d bytes, want %d", len(w.tail), startupTailSize)
+	}
+	select {
+	case <-w.matched:
+		t.Error("matched text that fell out of the tail")
+	default:
+	}
+}
+
+func TestANSIEscapeStripping(t *testing.T) {
+	tests := map[string]string{
+		"\x1b[01;32muser@host\x1b[00m$ ": "user@host$ ",
+		"\x1b]0;title\x07prompt> ":       "prompt> ",
+		"\x1b]0;title\x1b\\prompt> ":     "prompt> ",
+		"\x1b[?2004hroot# ":              "root# ",
+		"plain $ ":                       "plain $ ",
+		"\x1b=keypad\x1b>":               "keypad",
+	}
+	for in, want := range tests {
+		if got := ansiEscape.ReplaceAllString(in, ""); got != want {
+			t.Errorf("strip(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
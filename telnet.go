@@ -0,0 +1,341 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TelnetParams describes a telnet session
+type TelnetParams struct {
+	Host         string `json:"host"`
+	Port         int    `json:"port"`                   // default 23
+	TimeoutSec   int    `json:"timeoutSec,omitempty"`   // default 10
+	TerminalType string `json:"terminalType,omitempty"` // reported via TTYPE, default XTERM-256COLOR
+	// output kept for GetScrollback, 0 = 1 MiB
+	ScrollbackBytes int `json:"scrollbackBytes,omitempty"`
+}
+
+// Telnet commands and options (RFC 854, 857, 858, 1073, 1091)
+const (
+	telIAC  = 255
+	telDONT = 254
+	telDO   = 253
+	telWONT = 252
+	telWILL = 251
+	telSB   = 250
+	telSE   = 240
+
+	telOptEcho  = 1
+	telOptSGA   = 3
+	telOptTType = 24
+	telOptNAWS  = 31
+
+	telTTypeIs   = 0
+	telTTypeSend = 1
+)
+
+// StartTelnet connects to a telnet server as a terminal session. The global
+// proxy applies as for SSH.
+func (tm *TermManager) StartTelnet(p TelnetParams) (string, error) {
+	if p.Host == "" {
+		return "", errors.New("host required")
+	}
+	if p.Port == 0 {
+		p.Port = 23
+	}
+	timeout := 10 * time.Second
+	if p.TimeoutSec > 0 {
+		timeout = time.Duration(p.TimeoutSec) * time.Second
+	}
+	addr := net.JoinHostPort(p.Host, strconv.Itoa(p.Port))
+	conn, err := dialTCP(tm.effectiveProxy(ProxyConfig{}), addr, timeout)
+	if err != nil {
+		return "", err
+	}
+	t := &telnetConn{conn: conn, ttype: p.TerminalType}
+	if t.ttype == "" {
+		t.ttype = "XTERM-256COLOR"
+	}
+	return tm.startBackend(t, "telnet:"+addr, 0, 0, p.ScrollbackBytes)
+}
+
+// telnetConn is a telnet client speaking just enough of the protocol for an
+// interactive terminal: NAWS, TTYPE, ECHO and SGA. Everything else is refused.
+type telnetConn struct {
+	conn  net.Conn
+	ttype string
+
+	wmu        sync.Mutex // serializes writes from Send and negotiation replies
+	cols, rows int        // guarded by wmu
+	naws       bool       // server accepted NAWS, guarded by wmu
+
+	// parser state, only touched by Start and then Read
+	state  int
+	verb   byte      // DO/DONT/WILL/WONT awaiting its option byte
+	sbData []byte    // option and payload of the current subnegotiation
+	local  [256]bool // options we perform (WILL)
+	remote [256]bool // options the server performs (DO)
+
+	closed atomic.Bool
+	err    error
+}
+
+// parser states
+const (
+	telData = iota
+	telCmd
+	telOpt
+	telSub
+	telSubIAC
+	telCR
+)
+
+func (t *telnetConn) Start(cols, rows int) error {
+	t.wmu.Lock()
+	t.cols, t.rows = cols, rows
+	t.wmu.Unlock()
+	// announce what we support up front; the server acknowledges or refuses
+	for _, opt := range []byte{telOptNAWS, telOptTType, telOptSGA} {
+		t.local[opt] = true
+	}
+	for _, opt := range []byte{telOptEcho, telOptSGA} {
+		t.remote[opt] = true
+	}
+	return t.send(
+		telIAC, telWILL, telOptNAWS,
+		telIAC, telWILL, telOptTType,
+		telIAC, telDO, telOptEcho,
+		telIAC, telDO, telOptSGA,
+		telIAC, telWILL, telOptSGA,
+	)
+}
+
+func (t *telnetConn) Resize(cols, rows int) error {
+	t.wmu.Lock()
+	defer t.wmu.Unlock()
+	t.cols, t.rows = cols, rows
+	if !t.naws {
+		return nil
+	}
+	return t.writeLocked(t.nawsFrame())
+}
+
+// nawsFrame reports the window size, doubling any 255 byte as required
+func (t *telnetConn) nawsFrame() []byte {
+	b := []byte{telIAC, telSB, telOptNAWS}
+	for _, v := range []int{t.cols, t.rows} {
+		hi, lo := byte(v>>8), byte(v)
+		b = append(b, hi)
+		if hi == telIAC {
+			b = append(b, telIAC)
+		}
+		b = append(b, lo)
+		if lo == telIAC {
+			b = append(b, telIAC)
+		}
+	}
+	return append(b, telIAC, telSE)
+}
+
+func (t *telnetConn) send(b ...byte) error {
+	t.wmu.Lock()
+	defer t.wmu.Unlock()
+	return t.writeLocked(b)
+}
+
+func (t *telnetConn) writeLocked(b []byte) error {
+	_, err := t.conn.Write(b)
+	return err
+}
+
+// Write sends terminal input, escaping IAC and turning a bare CR into CR NUL
+func (t *telnetConn) Write(p []byte) (int, error) {
+	out := make([]byte, 0, len(p)+8)
+	for i, c := range p {
+		out = append(out, c)
+		switch {
+		case c == telIAC:
+			out = append(out, telIAC)
+		case c == '\r' && (i+1 == len(p) || p[i+1] != '\n'):
+			out = append(out, 0)
+		}
+	}
+	t.wmu.Lock()
+	defer t.wmu.Unlock()
+	if err := t.writeLocked(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Read returns terminal output with telnet commands removed and answered
+func (t *telnetConn) Read(p []byte) (int, error) {
+	buf := make([]byte, len(p))
+	for {
+		n, err := t.conn.Read(buf)
+		out := t.filter(buf[:n], p[:0])
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !t.closed.Load() {
+				t.err = err
+			}
+			if len(out) > 0 {
+				return len(out), nil
+			}
+			return 0, io.EOF
+		}
+		if len(out) > 0 {
+			return len(out), nil
+		}
+		// only negotiation in this read
+	}
+}
+
+// filter runs the telnet state machine over in, appending data bytes to out.
+// Data never grows, so out can share p's backing array.
+func (t *telnetConn) filter(in, out []byte) []byte {
+	for _, c := range in {
+		switch t.state {
+		case telData:
+			if c == telIAC {
+				t.state = telCmd
+				continue
+			}
+			out = append(out, c)
+			if c == '\r' {
+				t.state = telCR
+			}
+		case telCR:
+			// CR NUL means a bare carriage return
+			t.state = telData
+			if c == 0 {
+				continue
+			}
+			if c == telIAC {
+				t.state = telCmd
+				continue
+			}
+			out = append(out, c)
+			if c == '\r' {
+				t.state = telCR
+			}
+		case telCmd:
+			switch c {
+			case telIAC:
+				out = append(out, telIAC)
+				t.state = telData
+			case telDO, telDONT, telWILL, telWONT:
+				t.verb = c
+				t.state = telOpt
+			case telSB:
+				t.sbData = t.sbData[:0]
+				t.state = telSub
+			default:
+				t.state = telData // NOP, GA, etc.
+			}
+		case telOpt:
+			t.negotiate(t.verb, c)
+			t.state = telData
+		case telSub:
+			if c == telIAC {
+				t.state = telSubIAC
+				continue
+			}
+			t.sbData = append(t.sbData, c)
+		case telSubIAC:
+			switch c {
+			case telSE:
+				t.subnegotiate()
+				t.state = telData
+			case telIAC:
+				t.sbData = append(t.sbData, telIAC)
+				t.state = telSub
+			default:
+				t.state = telSub
+			}
+		}
+	}
+	return out
+}
+
+// negotiate answers DO/DONT/WILL/WONT. Requests that match the current state
+// are acknowledgements and get no reply, so the exchange cannot loop.
+func (t *telnetConn) negotiate(verb, opt byte) {
+	var reply []byte
+	switch verb {
+	case telDO:
+		switch opt {
+		case telOptNAWS, telOptTType, telOptSGA:
+			if !t.local[opt] {
+				t.local[opt] = true
+				reply = []byte{telIAC, telWILL, opt}
+			}
+			if opt == telOptNAWS {
+				t.wmu.Lock()
+				// a repeated DO is an acknowledgement; the size was already sent
+				if !t.naws && t.cols > 0 && t.rows > 0 {
+					reply = append(reply, t.nawsFrame()...)
+				}
+				t.naws = true
+				t.wmu.Unlock()
+			}
+		default:
+			reply = []byte{telIAC, telWONT, opt}
+		}
+	case telDONT:
+		if t.local[opt] {
+			t.local[opt] = false
+			reply = []byte{telIAC, telWONT, opt}
+		}
+		if opt == telOptNAWS {
+			t.wmu.Lock()
+			t.naws = false
+			t.wmu.Unlock()
+		}
+	case telWILL:
+		switch opt {
+		case telOptEcho, telOptSGA:
+			if !t.remote[opt] {
+				t.remote[opt] = true
+				reply = []byte{telIAC, telDO, opt}
+			}
+		default:
+			reply = []byte{telIAC, telDONT, opt}
+		}
+	case telWONT:
+		if t.remote[opt] {
+			t.remote[opt] = false
+			reply = []byte{telIAC, telDONT, opt}
+		}
+	}
+	if len(reply) > 0 {
+		if err := t.send(reply...); err != nil {
+			log.Printf("[Telnet] negotiation reply failed: %v", err)
+		}
+	}
+}
+
+func (t *telnetConn) subnegotiate() {
+	if len(t.sbData) >= 2 && t.sbData[0] == telOptTType && t.sbData[1] == telTTypeSend {
+		b := append([]byte{telIAC, telSB, telOptTType, telTTypeIs}, t.ttype...)
+		if err := t.send(append(b, telIAC, telSE)...); err != nil {
+			log.Printf("[Telnet] TTYPE reply failed: %v", err)
+		}
+	}
+}
+
+func (t *telnetConn) Wait() (int, error) {
+	return -1, t.err
+}
+
+func (t *telnetConn) Close() error {
+	if t.closed.Swap(true) {
+		return nil
+	}
+	return t.conn.Close()
+}
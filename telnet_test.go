@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+// telnetPipe returns a telnetConn whose server side is a net.Pipe stand-in, plus
+// a func that ends the connection and returns everything the client sent
+func telnetPipe(t *testing.T) (*telnetConn, net.Conn, func() []byte) {
+	t.Helper()
+	client, server := net.Pipe()
+	tc := &telnetConn{conn: client, ttype: "XTERM-256COLOR"}
+	var sent []byte
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, 512)
+		for {
+			n, err := server.Read(buf)
+			sent = append(sent, buf[:n]...)
+			if err != nil {
+				return
+			}
+		}
+	}()
+	t.Cleanup(func() { _ = server.Close() })
+	return tc, server, func() []byte {
+		_ = tc.Close()
+		<-done
+		return sent
+	}
+}
+
+func TestTelnetFilter(t *testing.T) {
+	iac := func(b ...byte) []byte { return append([]byte{telIAC}, b...) }
+	cat := func(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+	started := func(tc *telnetConn) {
+		for _, opt := range []byte{telOptNAWS, telOptTType, telOptSGA} {
+			tc.local[opt] = true
+		}
+		for _, opt := range []byte{telOptEcho, telOptSGA} {
+			tc.remote[opt] = true
+		}
+	}
+	sized := func(cols, rows int) func(*telnetConn) {
+		return func(tc *telnetConn) { started(tc); tc.cols, tc.rows = cols, rows }
+	}
+
+	tests := []struct {
+		name  string
+		setup func(*telnetConn)
+		in    [][]byte
+		data  string
+		reply []byte
+	}{
+		{name: "plain data", in: [][]byte{[]byte("hello\r\n")}, data: "hello\r\n"},
+		{name: "CR NUL is a bare CR", in: [][]byte{[]byte("a\r\x00b")}, data: "a\rb"},
+		{name: "CR NUL split across reads", in: [][]byte{[]byte("a\r"), []byte("\x00b")}, data: "a\rb"},
+		{name: "doubled IAC is data", in: [][]byte{cat([]byte("a"), iac(telIAC), []byte("b"))}, data: "a\xffb"},
+		{name: "command split across reads", in: [][]byte{[]byte("x"), iac(), []byte{telWILL}, []byte{telOptEcho, 'y'}}, data: "xy", reply: iac(telDO, telOptEcho)},
+		{name: "NOP is dropped", in: [][]byte{cat([]byte("a"), iac(241), []byte("b"))}, data: "ab"},
+		{name: "DO TTYPE accepted", in: [][]byte{iac(telDO, telOptTType)}, reply: iac(telWILL, telOptTType)},
+		{name: "DO unsupported refused", in: [][]byte{iac(telDO, 34)}, reply: iac(telWONT, 34)},
+		{name: "WILL unsupported refused", in: [][]byte{iac(telWILL, 34)}, reply: iac(telDONT, 34)},
+		{
+			name:  "repeated DO and WILL acknowledged once",
+			in:    [][]byte{cat(iac(telDO, telOptSGA), iac(telDO, telOptSGA), iac(telWILL, telOptEcho), iac(telWILL, telOptEcho))},
+			reply: cat(iac(telWILL, telOptSGA), iac(telDO, telOptEcho)),
+		},
+		{name: "DO and WILL after Start are acknowledgements", setup: started, in: [][]byte{cat(iac(telDO, telOptTType), iac(telWILL, telOptEcho))}},
+		{
+			name:  "WONT ECHO answered once",
+			setup: started,
+			in:    [][]byte{cat(iac(telWONT, telOptEcho), iac(telWONT, telOptEcho))},
+			reply: iac(telDONT, telOptEcho),
+		},
+		{
+			name:  "DO NAWS sends the size once",
+			setup: sized(80, 24),
+			in:    [][]byte{cat(iac(telDO, telOptNAWS), iac(telDO, telOptNAWS))},
+			reply: cat(iac(telSB, telOptNAWS, 0, 80, 0, 24), iac(telSE)),
+		},
+		{
+			name:  "NAWS doubles 255",
+			setup: sized(255, 511),
+			in:    [][]byte{iac(telDO, telOptNAWS)},
+			reply: cat(iac(telSB, telOptNAWS, 0, telIAC, telIAC, 1, telIAC, telIAC), iac(telSE)),
+		},
+		{
+			name:  "TTYPE SEND answered with IS",
+			setup: started,
+			in:    [][]byte{cat(iac(telSB, telOptTType, telTTypeSend), iac(telSE))},
+			reply: cat(iac(telSB, telOptTType, telTTypeIs), []byte("XTERM-256COLOR"), iac(telSE)),
+		},
+		{
+			name:  "other subnegotiation ignored",
+			setup: started,
+			in:    [][]byte{cat(iac(telSB, 34, 1, telIAC, telIAC, 2), iac(telSE), []byte("ok"))},
+			data:  "ok",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc, _, written := telnetPipe(t)
+			if tt.setup != nil {
+				tt.setup(tc)
+			}
+			var data []byte
+			for _, in := range tt.in {
+				data = tc.filter(in, data)
+			}
+			if string(data) != tt.data {
+				t.Errorf("data = %q, want %q", data, tt.data)
+			}
+			if got := written(); !bytes.Equal(got, tt.reply) {
+				t.Errorf("reply = %v, want %v", got, tt.reply)
+			}
+		})
+	}
+}
+
+func TestTelnetWrite(t *testing.T) {
+	tc, _, written := telnetPipe(t)
+	if _, err := tc.Write([]byte("a\xff\rb\r\n\r")); err != nil {
+		t.Fatal(err)
+	}
+	want := []byte("a\xff\xff\r\x00b\r\n\r\x00")
+	if got := written(); !bytes.Equal(got, want) {
+		t.Errorf("sent %q, want %q", got, want)
+	}
+}
+
+func TestTelnetReadAnswersNegotiation(t *testing.T) {
+	tc, server, written := telnetPipe(t)
+	go func() {
+		_, _ = server.Write([]byte{telIAC, telDO, telOptTType, 'h', 'i', telIAC, telIAC})
+	}()
+	buf := make([]byte, 64)
+	n, err := tc.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(buf[:n]); got != "hi\xff" {
+		t.Errorf("read %q, want %q", got, "hi\xff")
+	}
+	if got, want := written(), []byte{telIAC, telWILL, telOptTType}; !bytes.Equal(got, want) {
+		t.Errorf("reply = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// termBackend is a terminal transport other than SSH (local PTY, serial port,
+// telnet). Sessions backed by one keep the Send/Resize/Close, recording,
+// scrollback and term:* event contract of SSH sessions but have no *ssh.Client.
+type termBackend interface {
+	io.Reader // terminal output
+	io.Writer // terminal input
+	// Start is called once with the first known terminal size
+	Start(cols, rows int) error
+	Resize(cols, rows int) error
+	// Wait blocks until the output has ended and returns the exit code (-1 when
+	// there is none) or the error that broke the stream
+	Wait() (int, error)
+	Close() error
+}
+
+// startBackend registers a session for b. Like StartSSH it starts right away when
+// the size is known, otherwise on the first Resize.
+func (tm *TermManager) startBackend(b termBackend, title string, cols, rows, scrollbackBytes int) (string, error) {
+	id := fmt.Sprintf("%d", time.Now().UnixNano())
+	sess := &sshSession{
+		id: id, host: title, backend: b,
+		closed: make(chan struct{}), quit: make(chan struct{}),
+		forwards: make(map[string]*portForward), scrollback: newRingBuffer(scrollbackBytes),
+	}
+	tm.mu.Lock()
+	tm.sessions[id] = sess
+	tm.mu.Unlock()
+
+	if cols > 0 && rows > 0 {
+		if err := b.Start(cols, rows); err != nil {
+			tm.take(id)
+			_ = b.Close()
+			return "", err
+		}
+		sess.started = true
+		sess.cols, sess.rows = cols, rows
+		go tm.pumpOutput(sess)
+		tm.emitStarted(sess)
+	}
+	return id, nil
+}
+
+// pumpBackend relays the output of a backend session until it ends
+func (tm *TermManager) pumpBackend(ss *sshSession, writer *evtWriter) {
+	_, _ = io.Copy(writer, ss.backend)
+	writer.flush()
+	code, err := ss.backend.Wait()
+	tm.emitClosed(closeInfoBackend(ss, code, err))
+}
+
+// getSSH is get restricted to SSH sessions, for operations that need the *ssh.Client
+func (tm *TermManager) getSSH(id string) (*sshSession, bool) {
+	s, ok := tm.get(id)
+	if !ok || s.backend != nil {
+		return nil, false
+	}
+	return s, true
+}
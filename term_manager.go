@@ -9,9 +9,11 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/crypto/ssh"
@@ -25,20 +27,51 @@ type TermManager struct {
 	ctx      context.Context
 	mu       sync.Mutex
 	sessions map[string]*sshSession
+
+	promptMu sync.Mutex
+	prompts  map[string]chan promptReply
+
+	connMu sync.Mutex
+	conns  map[string]*sharedConn
+
+	masterKey   []byte
+	proxyMu     sync.Mutex
+	globalProxy ProxyConfig
+
+	execMu sync.Mutex
+	execs  map[string]context.CancelFunc // running RunCommand/StartCommand calls
 }
 
-// Local port forwarding implementation
-type localForward struct {
-	id   string
-	ln   net.Listener
-	stop chan struct{}
-	wg   sync.WaitGroup
+// Forward kinds
+const (
+	forwardLocal   = "local"   // listen locally, dial through SSH (ssh -L)
+	forwardRemote  = "remote"  // listen on the server, dial locally (ssh -R)
+	forwardDynamic = "dynamic" // local SOCKS5 server, dial through SSH (ssh -D)
+)
+
+// Port forwarding implementation. laddr is where the listener binds, raddr is
+// dialed for every accepted connection (dynamic forwards ask the SOCKS client).
+type portForward struct {
+	id    string
+	kind  string
+	laddr string
+	raddr string
+	auth  *socksAuth // credentials required by a dynamic forward, nil for none
+	ln    net.Listener
+	stop  chan struct{}
+	wg    sync.WaitGroup
+
+	stats forwardStats // reported by ListForwards and forward:stats
 }
 
-func (f *localForward) stopNow() {
+func (f *portForward) stopNow() {
+	if f.ln == nil {
+		return // already stopped, e.g. while its session reconnects
+	}
 	close(f.stop)
 	_ = f.ln.Close()
 	f.wg.Wait()
+	f.ln = nil
 }
 
 // StartWebProxyViaSSH creates HTTP proxy using SSH command execution (no port forwarding needed)
@@ -48,7 +81,7 @@ func (tm *TermManager) StartWebProxyViaSSH(id string, localPort int, remoteHost
 
 // StartLocalForward starts L-forward on a session. Returns forward id.
 func (tm *TermManager) StartLocalForward(id string, localHost string, localPort int, remoteHost string, remotePort int) (string, error) {
-	s, ok := tm.get(id)
+	s, ok := tm.getSSH(id)
 	if !ok {
 		return "", errors.New("session not found")
 	}
@@ -60,15 +93,31 @@ func (tm *TermManager) StartLocalForward(id string, localHost string, localPort
 	}
 	laddr := net.JoinHostPort(localHost, strconv.Itoa(localPort))
 	raddr := net.JoinHostPort(remoteHost, strconv.Itoa(remotePort))
-	ln, err := net.Listen("tcp", laddr)
-	if err != nil {
+	f := &portForward{id: fmt.Sprintf("fwd-%d", time.Now().UnixNano()), kind: forwardLocal, laddr: laddr, raddr: raddr}
+	if err := tm.serveForward(s, f); err != nil {
 		return "", err
 	}
-	fid := fmt.Sprintf("fwd-%d", time.Now().UnixNano())
-	f := &localForward{id: fid, ln: ln, stop: make(chan struct{})}
 	s.fwdMu.Lock()
-	s.forwards[fid] = f
+	s.forwards[f.id] = f
 	s.fwdMu.Unlock()
+	return f.id, nil
+}
+
+// serveForward (re)opens f's listener and relays accepted connections over s
+func (tm *TermManager) serveForward(s *sshSession, f *portForward) error {
+	// local forwards listen here and dial through SSH, remote forwards the other way round
+	listen, dial := net.Listen, s.sshClient().Dial
+	if f.kind == forwardRemote {
+		listen = func(_, addr string) (net.Listener, error) { return tm.listenRemote(s.sshConn(), addr) }
+		dial = func(network, addr string) (net.Conn, error) { return net.DialTimeout(network, addr, 10*time.Second) }
+	}
+	ln, err := listen("tcp", f.laddr)
+	if err != nil {
+		return err
+	}
+	f.ln = ln
+	f.stop = make(chan struct{})
+	raddr := f.raddr
 	f.wg.Add(1)
 	go func() {
 		defer f.wg.Done()
@@ -80,39 +129,57 @@ func (tm *TermManager) StartLocalForward(id string, localHost string, localPort
 					return
 				default:
 				}
+				if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+					return // a remote listener dies with its connection
+				}
 				continue
 			}
 			// handle connection
+			f.stats.conns.Add(1)
+			f.stats.active.Add(1)
 			go func(c net.Conn) {
+				defer f.stats.active.Add(-1)
 				defer c.Close()
-				log.Printf("[Port Forward] New connection from %s to %s", c.RemoteAddr(), raddr)
-
-				// dial remote through SSH
-				rc, err := s.client.Dial("tcp", raddr)
-				if err != nil {
-					log.Printf("[Port Forward] ERROR: Failed to dial remote %s: %v", raddr, err)
-					return
+				raddr := raddr
+				var rc net.Conn
+				var err error
+				if f.kind == forwardDynamic {
+					// the SOCKS client names the target
+					if raddr, rc, err = socks5Serve(c, f.auth, dial); err != nil {
+						log.Printf("[Port Forward] ERROR: SOCKS5 request from %s: %v", c.RemoteAddr(), err)
+						f.stats.fail(err)
+						return
+					}
+				} else {
+					log.Printf("[Port Forward] New connection from %s to %s", c.RemoteAddr(), raddr)
+					if rc, err = dial("tcp", raddr); err != nil {
+						log.Printf("[Port Forward] ERROR: Failed to dial %s: %v", raddr, err)
+						f.stats.fail(err)
+						return
+					}
 				}
 				defer rc.Close()
-				log.Printf("[Port Forward] Connected to remote %s", raddr)
+				log.Printf("[Port Forward] Connected to %s", raddr)
 
 				// bidirectional copy
 				var wg sync.WaitGroup
 				wg.Add(2)
 				go func() {
 					defer wg.Done()
-					n, err := io.Copy(rc, c)
+					n, err := io.Copy(countWriter{rc, &f.stats.bytesOut}, c)
 					if err != nil {
 						log.Printf("[Port Forward] ERROR: Client->Remote copy failed after %d bytes: %v", n, err)
+						f.stats.fail(err)
 					} else {
 						log.Printf("[Port Forward] Client->Remote copy completed: %d bytes", n)
 					}
 				}()
 				go func() {
 					defer wg.Done()
-					n, err := io.Copy(c, rc)
+					n, err := io.Copy(countWriter{c, &f.stats.bytesIn}, rc)
 					if err != nil {
 						log.Printf("[Port Forward] ERROR: Remote->Client copy failed after %d bytes: %v", n, err)
+						f.stats.fail(err)
 					} else {
 						log.Printf("[Port Forward] Remote->Client copy completed: %d bytes", n)
 					}
@@ -122,16 +189,22 @@ func (tm *TermManager) StartLocalForward(id string, localHost string, localPort
 			}(conn)
 		}
 	}()
-	return fid, nil
+	return nil
 }
 
 func (tm *TermManager) StopLocalForward(id string, forwardId string) error {
+	return tm.stopForward(id, forwardId, forwardLocal)
+}
+
+// stopForward stops and unregisters a forward of the given kind
+func (tm *TermManager) stopForward(id, forwardId, kind string) error {
 	s, ok := tm.get(id)
 	if !ok {
 		return errors.New("session not found")
 	}
 	s.fwdMu.Lock()
 	f, ok := s.forwards[forwardId]
+	ok = ok && f.kind == kind
 	if ok {
 		delete(s.forwards, forwardId)
 	}
@@ -143,34 +216,25 @@ func (tm *TermManager) StopLocalForward(id string, forwardId string) error {
 	return nil
 }
 
-// evtWriter implements io.Writer to forward SSH output to frontend & recorder
-type evtWriter struct {
-	tm *TermManager
-	ss *sshSession
-}
-
-func (w *evtWriter) Write(p []byte) (int, error) {
-	if len(p) == 0 {
-		return 0, nil
-	}
-	chunk := string(p)
-	runtime.EventsEmit(w.tm.ctx, "term:data:"+w.ss.id, chunk)
-	w.tm.appendRecord(w.ss, chunk)
-	return len(p), nil
-}
-
 type sshSession struct {
-	id      string
-	host    string
-	port    int
-	user    string
+	id     string
+	host   string
+	port   int
+	user   string
+	params SSHParams // kept to redial on reconnect
+	closed chan struct{}
+	quit   chan struct{} // closed by Close; aborts a pending reconnect
+
+	// mu guards the fields below, which are replaced when the session reconnects
+	mu      sync.Mutex
 	client  *ssh.Client
 	sess    *ssh.Session
 	stdin   io.WriteCloser
 	stdout  io.Reader
 	stderr  io.Reader
-	closed  chan struct{}
 	started bool
+	cols    int // last PTY size
+	rows    int
 
 	recMu    sync.Mutex
 	recOn    bool
@@ -179,10 +243,20 @@ type sshSession struct {
 	recLines bool
 	lineNo   int
 
-	gateway *ssh.Client
+	conn *sharedConn // multiplexed connection that client belongs to, guarded by mu
 
 	fwdMu    sync.Mutex
-	forwards map[string]*localForward
+	forwards map[string]*portForward
+
+	prompt atomic.Pointer[promptWatcher] // set while startup commands wait for a prompt
+
+	bytesIn  atomic.Int64 // totals reported on term:closed
+	bytesOut atomic.Int64
+
+	binary     atomic.Bool // emit output base64-encoded on term:data64 instead of as text
+	scrollback *ringBuffer // recent output for GetScrollback
+
+	backend termBackend // local/serial/telnet transport; nil for SSH sessions
 }
 
 type SSHParams struct {
@@ -190,243 +264,202 @@ type SSHParams struct {
 	Port         int
 	Username     string
 	Password     string
-	AuthType     string // "password" | "key" (key not yet implemented in MVP)
+	AuthType     string // "password" | "key" | "agent"
 	KeyPEM       string // optional
 	Passphrase   string // optional
+	CertPub      string // optional OpenSSH user certificate (id_*-cert.pub) for KeyPEM
 	Cols         int
 	Rows         int
 	KeepAliveSec int // 0=off
 	TimeoutSec   int // default 10
-	// ProxyJump (single hop)
-	GatewayHost       string
-	GatewayPort       int
-	GatewayUser       string
-	GatewayAuth       string // password | key
-	GatewayPassword   string
-	GatewayKeyPEM     string
-	GatewayPassphrase string
+	// "" (UTF-8 text on term:data) | "base64" (raw bytes on term:data64)
+	OutputEncoding string
+	// output kept for GetScrollback, 0 = 1 MiB
+	ScrollbackBytes int
+	// negotiation overrides for legacy devices; empty lists keep the defaults
+	AlgorithmPreset   string // "" | "legacy"
+	KeyExchanges      []string
+	Ciphers           []string
+	MACs              []string
+	HostKeyAlgorithms []string
+	// agent forwarding: "" (off) | "system" | "vault"; "vault" exposes only this
+	// profile's own key, never the keys of other saved profiles
+	ForwardAgent string
+	// redial with exponential backoff when the connection drops
+	AutoReconnect     bool
+	ReconnectMaxTries int // 0=until the tab is closed
+	// environment sent before the shell starts (subject to the server's AcceptEnv)
+	Env map[string]string
+	// typed in order once the shell starts; with PromptPattern each waits for a matching prompt
+	StartupCommands []string
+	PromptPattern   string // regexp matched against output with escape sequences stripped
+	// ProxyJump chain, dialed in order before the target
+	Jumps []JumpHost
+	// outbound proxy for the first hop; Type "" falls back to the global proxy
+	Proxy ProxyConfig
+	// started once connected; failures are reported on forward:failed
+	Forwards []ForwardSpec
+}
+
+// normalize fills in defaults and validates p; every path that dials SSHParams
+// goes through it so pool keys and keepalives match between tabs and commands.
+func (p *SSHParams) normalize() error {
+	if p.Host == "" || p.Username == "" {
+		return errors.New("host/username required")
+	}
+	if p.Port == 0 {
+		p.Port = 22
+	}
+	if p.AutoReconnect && p.KeepAliveSec == 0 {
+		p.KeepAliveSec = reconnectKeepAliveSec
+	}
+	if p.PromptPattern != "" {
+		if _, err := regexp.Compile(p.PromptPattern); err != nil {
+			return fmt.Errorf("invalid prompt pattern: %w", err)
+		}
+	}
+	return nil
 }
 
 func NewTermManager() *TermManager {
-	return &TermManager{sessions: make(map[string]*sshSession)}
+	return &TermManager{
+		sessions: make(map[string]*sshSession),
+		prompts:  make(map[string]chan promptReply),
+		conns:    make(map[string]*sharedConn),
+		execs:    make(map[string]context.CancelFunc),
+	}
 }
 
 func (tm *TermManager) startup(ctx context.Context) {
 	tm.ctx = ctx
 	_ = store.EnsureDirs()
-	_, _ = store.LoadOrCreateMasterKey()
+	tm.masterKey, _ = store.LoadOrCreateMasterKey()
+	tm.loadGlobalProxy()
+	go tm.emitForwardStats()
 }
 
 func (tm *TermManager) StartSSH(p SSHParams) (string, error) {
-	if p.Host == "" || p.Username == "" {
-		return "", errors.New("host/username required")
-	}
-	if p.Port == 0 {
-		p.Port = 22
-	}
-	addr := net.JoinHostPort(p.Host, strconv.Itoa(p.Port))
-
-	// build auth
-	var authMethods []ssh.AuthMethod
-	switch p.AuthType {
-	case "password", "":
-		authMethods = append(authMethods, ssh.Password(p.Password))
-	case "key":
-		if p.KeyPEM == "" {
-			return "", errors.New("key auth requires KeyPEM")
-		}
-		var signer ssh.Signer
-		if p.Passphrase != "" {
-			s, err := ssh.ParsePrivateKeyWithPassphrase([]byte(p.KeyPEM), []byte(p.Passphrase))
-			if err != nil {
-				return "", fmt.Errorf("parse key: %w", err)
-			}
-			signer = s
-		} else {
-			s, err := ssh.ParsePrivateKey([]byte(p.KeyPEM))
-			if err != nil {
-				return "", fmt.Errorf("parse key: %w", err)
-			}
-			signer = s
-		}
-		authMethods = append(authMethods, ssh.PublicKeys(signer))
-	default:
-		return "", fmt.Errorf("unsupported auth type: %s", p.AuthType)
-	}
-
-	cfg := &ssh.ClientConfig{
-		User:            p.Username,
-		Auth:            authMethods,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-		Timeout: func() time.Duration {
-			if p.TimeoutSec > 0 {
-				return time.Duration(p.TimeoutSec) * time.Second
-			}
-			return 10 * time.Second
-		}(),
-	}
-
-	// dial target (optionally via gateway)
-	var client *ssh.Client
-	var gatewayClient *ssh.Client
-	var err error
-	if p.GatewayHost != "" {
-		gwAddr := net.JoinHostPort(p.GatewayHost, strconv.Itoa(func() int {
-			if p.GatewayPort > 0 {
-				return p.GatewayPort
-			}
-			return 22
-		}()))
-		log.Printf("[ProxyJump] 正在连接跳板机: %s@%s", p.GatewayUser, gwAddr)
-
-		var gwMethods []ssh.AuthMethod
-		if p.GatewayAuth == "key" {
-			if p.GatewayKeyPEM == "" {
-				return "", errors.New("跳板机密钥认证需要提供私钥")
-			}
-			var gwSigner ssh.Signer
-			if p.GatewayPassphrase != "" {
-				s, e := ssh.ParsePrivateKeyWithPassphrase([]byte(p.GatewayKeyPEM), []byte(p.GatewayPassphrase))
-				if e != nil {
-					return "", fmt.Errorf("解析跳板机私钥失败: %w", e)
-				}
-				gwSigner = s
-			} else {
-				s, e := ssh.ParsePrivateKey([]byte(p.GatewayKeyPEM))
-				if e != nil {
-					return "", fmt.Errorf("解析跳板机私钥失败: %w", e)
-				}
-				gwSigner = s
-			}
-			gwMethods = append(gwMethods, ssh.PublicKeys(gwSigner))
-			log.Printf("[ProxyJump] 跳板机使用私钥认证")
-		} else {
-			if p.GatewayPassword == "" {
-				return "", errors.New("跳板机密码认证需要提供密码")
-			}
-			gwMethods = append(gwMethods, ssh.Password(p.GatewayPassword))
-			log.Printf("[ProxyJump] 跳板机使用密码认证")
-		}
-		gwCfg := &ssh.ClientConfig{User: p.GatewayUser, Auth: gwMethods, HostKeyCallback: ssh.InsecureIgnoreHostKey(), Timeout: cfg.Timeout}
-		gcli, e := ssh.Dial("tcp", gwAddr, gwCfg)
-		if e != nil {
-			return "", fmt.Errorf("连接跳板机失败 (%s@%s): %w", p.GatewayUser, gwAddr, e)
-		}
-		log.Printf("[ProxyJump] ✓ 跳板机连接成功")
-		gatewayClient = gcli
-
-		log.Printf("[ProxyJump] 通过跳板机连接目标主机: %s@%s", p.Username, addr)
-		conn, e := gatewayClient.Dial("tcp", addr)
-		if e != nil {
-			log.Printf("[ProxyJump] ⚠️  端口转发失败，尝试使用命令执行方式: %v", e)
-			// 如果端口转发被禁止，尝试使用在跳板机上执行ssh命令的方式
-			if strings.Contains(e.Error(), "administratively prohibited") || strings.Contains(e.Error(), "open failed") {
-				log.Printf("[ProxyJump] 检测到端口转发被禁止，使用备用方案")
-				_ = gatewayClient.Close()
-				return "", fmt.Errorf("跳板机禁止了端口转发功能。\n\n解决方案：\n1. 让管理员在跳板机的 /etc/ssh/sshd_config 中设置 AllowTcpForwarding yes\n2. 或者先连接到跳板机，再手动连接目标主机\n\n原始错误: %w", e)
-			}
-			_ = gatewayClient.Close()
-			return "", fmt.Errorf("通过跳板机连接目标主机失败 (%s): %w", addr, e)
-		}
-		cconn, chans, reqs, e := ssh.NewClientConn(conn, addr, cfg)
-		if e != nil {
-			_ = gatewayClient.Close()
-			return "", fmt.Errorf("目标主机认证失败 (%s@%s): %w", p.Username, addr, e)
-		}
-		log.Printf("[ProxyJump] ✓ 目标主机连接成功")
-		client = ssh.NewClient(cconn, chans, reqs)
-	} else {
-		client, err = ssh.Dial("tcp", addr, cfg)
-		if err != nil {
-			return "", err
-		}
-	}
-
-	s, err := client.NewSession()
-	if err != nil {
-		_ = client.Close()
+	if err := p.normalize(); err != nil {
 		return "", err
 	}
 
-	stdin, err := s.StdinPipe()
+	// reuse a live connection to the same host@user and jump chain, or dial a new one
+	conn, err := tm.acquireConn(context.Background(), p)
 	if err != nil {
-		_ = s.Close()
-		_ = client.Close()
 		return "", err
 	}
-	stdout, err := s.StdoutPipe()
+	client := conn.client
+
+	s, stdin, stdout, stderr, err := newShellSession(client)
 	if err != nil {
-		_ = s.Close()
-		_ = client.Close()
+		tm.releaseConn(conn)
 		return "", err
 	}
-	stderr, err := s.StderrPipe()
-	if err != nil {
-		_ = s.Close()
-		_ = client.Close()
-		return "", err
+	if p.ForwardAgent != "" {
+		if err := tm.enableAgentForwarding(conn, s, p); err != nil {
+			_ = s.Close()
+			tm.releaseConn(conn)
+			return "", err
+		}
 	}
 
 	id := fmt.Sprintf("%d", time.Now().UnixNano())
 	sess := &sshSession{
-		id: id, host: p.Host, port: p.Port, user: p.Username,
+		id: id, host: p.Host, port: p.Port, user: p.Username, params: p,
 		client: client, sess: s, stdin: stdin, stdout: stdout, stderr: stderr,
-		closed: make(chan struct{}), started: false, gateway: gatewayClient,
-		forwards: make(map[string]*localForward),
+		closed: make(chan struct{}), quit: make(chan struct{}), started: false, conn: conn,
+		forwards: make(map[string]*portForward), scrollback: newRingBuffer(p.ScrollbackBytes),
 	}
+	sess.binary.Store(p.OutputEncoding == outputBase64)
 
 	tm.mu.Lock()
 	tm.sessions[id] = sess
 	tm.mu.Unlock()
 
-	if p.KeepAliveSec > 0 {
-		interval := time.Duration(p.KeepAliveSec) * time.Second
-		go func(c *ssh.Client, ch <-chan struct{}) {
-			t := time.NewTicker(interval)
-			defer t.Stop()
-			for {
-				select {
-				case <-t.C:
-					_, _, _ = c.SendRequest("keepalive@openssh.com", true, nil)
-				case <-ch:
-					return
-				}
-			}
-		}(client, sess.closed)
-	}
-
 	// immediate start if initial size provided
 	if p.Cols > 0 && p.Rows > 0 {
-		modes := ssh.TerminalModes{ssh.ECHO: 1, ssh.TTY_OP_ISPEED: 14400, ssh.TTY_OP_OSPEED: 14400}
-		if err := s.RequestPty("xterm-256color", p.Rows, p.Cols, modes); err != nil {
+		if err := startShell(s, p.Cols, p.Rows, p.Env); err != nil {
+			tm.take(id)
 			_ = s.Close()
-			_ = client.Close()
-			return "", err
-		}
-		if err := s.Shell(); err != nil {
-			_ = s.Close()
-			_ = client.Close()
+			tm.releaseConn(conn)
 			return "", err
 		}
 		sess.started = true
+		sess.cols, sess.rows = p.Cols, p.Rows
 		go tm.pumpOutput(sess)
-		runtime.EventsEmit(tm.ctx, "term:started:"+id)
-		_, _ = io.WriteString(sess.stdin, "\r")
+		tm.emitStarted(sess)
+		tm.runStartup(sess, sess.stdin)
 	}
+	tm.startForwardSpecs(id, p.Forwards)
 	return id, nil
 }
 
+// newShellSession opens a session channel with its stdio pipes attached
+func newShellSession(client *ssh.Client) (*ssh.Session, io.WriteCloser, io.Reader, io.Reader, error) {
+	s, err := client.NewSession()
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	stdin, err := s.StdinPipe()
+	if err != nil {
+		_ = s.Close()
+		return nil, nil, nil, nil, err
+	}
+	stdout, err := s.StdoutPipe()
+	if err != nil {
+		_ = s.Close()
+		return nil, nil, nil, nil, err
+	}
+	stderr, err := s.StderrPipe()
+	if err != nil {
+		_ = s.Close()
+		return nil, nil, nil, nil, err
+	}
+	return s, stdin, stdout, stderr, nil
+}
+
+// startShell requests a PTY of the given size, sends env and starts the login shell
+func startShell(s *ssh.Session, cols, rows int, env map[string]string) error {
+	modes := ssh.TerminalModes{ssh.ECHO: 1, ssh.TTY_OP_ISPEED: 14400, ssh.TTY_OP_OSPEED: 14400}
+	if err := s.RequestPty("xterm-256color", rows, cols, modes); err != nil {
+		return err
+	}
+	setEnv(s, env)
+	return s.Shell()
+}
+
 func (tm *TermManager) pumpOutput(ss *sshSession) {
 	defer close(ss.closed)
-	writer := &evtWriter{tm: tm, ss: ss}
-	var wg sync.WaitGroup
-	wg.Add(2)
-	go func() { defer wg.Done(); _, _ = io.Copy(writer, ss.stdout) }()
-	go func() { defer wg.Done(); _, _ = io.Copy(writer, ss.stderr) }()
-	wg.Wait()
-	// notify frontend this session is closed
-	runtime.EventsEmit(tm.ctx, "term:closed:"+ss.id)
-	runtime.EventsEmit(tm.ctx, "term:closed", ss.id)
+	writer := newEvtWriter(tm, ss)
+	if ss.backend != nil {
+		tm.pumpBackend(ss, writer)
+		return
+	}
+	for {
+		ss.mu.Lock()
+		sess, stdout, stderr := ss.sess, ss.stdout, ss.stderr
+		ss.mu.Unlock()
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() { defer wg.Done(); _, _ = io.Copy(writer, stdout) }()
+		go func() { defer wg.Done(); _, _ = io.Copy(writer, stderr) }()
+		wg.Wait()
+		writer.flush()
+		// Wait may only be called once per session, so classify its error here
+		err := sess.Wait()
+		lost := transportLost(ss, err)
+		if !tm.shouldReconnect(ss, lost, err) || !tm.reconnect(ss) {
+			tm.emitClosed(closeInfo(ss, err, lost))
+			return
+		}
+	}
+}
+
+// emitClosed notifies the frontend that a session has ended
+func (tm *TermManager) emitClosed(ev SessionClosed) {
+	log.Printf("[Term] session %s ended (%s): %s", ev.ID, ev.Reason, ev.Message)
+	runtime.EventsEmit(tm.ctx, "term:closed:"+ev.ID, ev)
+	runtime.EventsEmit(tm.ctx, "term:closed", ev)
 }
 
 func (tm *TermManager) Send(id string, data string) error {
@@ -434,7 +467,14 @@ func (tm *TermManager) Send(id string, data string) error {
 	if !ok {
 		return errors.New("session not found")
 	}
-	_, err := io.WriteString(s.stdin, data)
+	s.mu.Lock()
+	var stdin io.Writer = s.stdin
+	if s.backend != nil {
+		stdin = s.backend
+	}
+	s.mu.Unlock()
+	n, err := io.WriteString(stdin, data)
+	s.bytesOut.Add(int64(n))
 	return err
 }
 
@@ -443,18 +483,29 @@ func (tm *TermManager) Resize(id string, cols, rows int) error {
 	if !ok {
 		return errors.New("session not found")
 	}
-	if !s.started {
-		modes := ssh.TerminalModes{ssh.ECHO: 1, ssh.TTY_OP_ISPEED: 14400, ssh.TTY_OP_OSPEED: 14400}
-		if err := s.sess.RequestPty("xterm-256color", rows, cols, modes); err != nil {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cols, s.rows = cols, rows
+	if s.backend != nil {
+		if s.started {
+			return s.backend.Resize(cols, rows)
+		}
+		if err := s.backend.Start(cols, rows); err != nil {
 			return err
 		}
-		if err := s.sess.Shell(); err != nil {
+		s.started = true
+		go tm.pumpOutput(s)
+		tm.emitStarted(s)
+		return nil
+	}
+	if !s.started {
+		if err := startShell(s.sess, cols, rows, s.params.Env); err != nil {
 			return err
 		}
 		s.started = true
 		go tm.pumpOutput(s)
-		runtime.EventsEmit(tm.ctx, "term:started:"+s.id)
-		_, _ = io.WriteString(s.stdin, "\r")
+		tm.emitStarted(s)
+		tm.runStartup(s, s.stdin)
 		return nil
 	}
 	return s.sess.WindowChange(rows, cols)
@@ -465,6 +516,7 @@ func (tm *TermManager) Close(id string) error {
 	if !ok {
 		return nil
 	}
+	close(s.quit)
 	// stop forwards
 	s.fwdMu.Lock()
 	for _, f := range s.forwards {
@@ -472,15 +524,35 @@ func (tm *TermManager) Close(id string) error {
 	}
 	s.fwdMu.Unlock()
 	tm.stopRecordingLocked(s)
-	_ = s.sess.Close()
-	_ = s.client.Close()
-	if s.gateway != nil {
-		_ = s.gateway.Close()
+	s.mu.Lock()
+	sess, conn, started := s.sess, s.conn, s.started
+	s.mu.Unlock()
+	if s.backend != nil {
+		_ = s.backend.Close()
+	} else {
+		_ = sess.Close()
+		tm.releaseConn(conn)
+	}
+	if started {
+		<-s.closed
 	}
-	<-s.closed
 	return nil
 }
 
+// sshClient returns the client the session currently runs on
+func (s *sshSession) sshClient() *ssh.Client {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.client
+}
+
+// sshConn returns the shared connection the session currently holds
+func (s *sshSession) sshConn() *sharedConn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn
+}
+
 func (tm *TermManager) get(id string) (*sshSession, bool) {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
@@ -564,6 +636,8 @@ func (tm *TermManager) appendRecord(s *sshSession, chunk string) {
 	if !s.recOn || s.recFile == nil {
 		return
 	}
+	// the recording is a markdown file, keep it valid UTF-8 whatever the output was
+	chunk = strings.ToValidUTF8(chunk, "\uFFFD")
 	ts := time.Now().Format("15:04:05")
 	if s.recLines {
 		lines := strings.Split(chunk, "\n")
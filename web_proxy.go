@@ -27,7 +27,7 @@ type WebProxySession struct {
 
 // StartWebProxy creates an HTTP proxy that forwards requests through SSH
 func (tm *TermManager) StartWebProxy(sessionID string, localPort int, remoteHost string, remotePort int) (string, error) {
-	s, ok := tm.get(sessionID)
+	s, ok := tm.getSSH(sessionID)
 	if !ok {
 		return "", errors.New("session not found")
 	}
@@ -40,7 +40,7 @@ func (tm *TermManager) StartWebProxy(sessionID string, localPort int, remoteHost
 
 	// Create HTTP handler that proxies through SSH
 	handler := &sshProxyHandler{
-		sshClient:  s.client,
+		sshClient:  s.sshClient(),
 		remoteHost: remoteHost,
 		remotePort: remotePort,
 	}
@@ -56,7 +56,7 @@ func (tm *TermManager) StartWebProxy(sessionID string, localPort int, remoteHost
 
 	proxy := &WebProxySession{
 		id:         proxyID,
-		sshClient:  s.client,
+		sshClient:  s.sshClient(),
 		remoteHost: remoteHost,
 		remotePort: remotePort,
 		localPort:  localPort,
@@ -77,7 +77,7 @@ func (tm *TermManager) StartWebProxy(sessionID string, localPort int, remoteHost
 	// Store proxy session
 	s.fwdMu.Lock()
 	if s.forwards == nil {
-		s.forwards = make(map[string]*localForward)
+		s.forwards = make(map[string]*portForward)
 	}
 	// Reuse forwards map for simplicity, but store proxy info
 	s.fwdMu.Unlock()